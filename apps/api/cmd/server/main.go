@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/falasefemi2/goreact-boilerplate/internal/config"
 	"github.com/falasefemi2/goreact-boilerplate/internal/server"
+	"github.com/falasefemi2/goreact-boilerplate/internal/storage"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -51,8 +53,15 @@ func main() {
 
 	slog.Info("database connected")
 
+	// Where uploaded files are kept
+	blobs, err := newBlobStore(cfg.Storage)
+	if err != nil {
+		slog.Error("failed to set up blob storage", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize router
-	handler := server.New(db, cfg)
+	handler, jobs := server.New(db, blobs, cfg)
 
 	// Create HTTP server using config timeouts
 	httpServer := &http.Server{
@@ -68,6 +77,13 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Start background jobs; they stop once the server has shut down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	var jobsDone sync.WaitGroup
+	for _, job := range jobs {
+		jobsDone.Go(func() { job(jobsCtx) })
+	}
+
 	// Start server
 	go func() {
 		slog.Info("starting server",
@@ -93,5 +109,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	stopJobs()
+	jobsDone.Wait()
+
 	slog.Info("server exited cleanly")
 }
+
+func newBlobStore(cfg config.StorageConfig) (storage.BlobStore, error) {
+	if cfg.Backend == "s3" {
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	}
+	return storage.NewLocalStore(cfg.LocalDir)
+}
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMPTZ;
+
+-- admin_id and target_user_id deliberately have no foreign keys:
+-- the trail must outlive the accounts it talks about
+CREATE TABLE admin_audit_log (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id        UUID NOT NULL,
+    target_user_id  UUID NOT NULL,
+    action          TEXT NOT NULL,
+    details         JSONB NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_admin_audit_log_target_user_id ON admin_audit_log(target_user_id, created_at DESC);
+CREATE INDEX idx_admin_audit_log_admin_id ON admin_audit_log(admin_id);
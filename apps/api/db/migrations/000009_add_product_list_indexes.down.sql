@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_products_user_lower_name;
+DROP INDEX IF EXISTS idx_products_user_name;
+DROP INDEX IF EXISTS idx_products_user_stock;
+DROP INDEX IF EXISTS idx_products_user_price;
+DROP INDEX IF EXISTS idx_products_user_updated;
+DROP INDEX IF EXISTS idx_products_user_created;
@@ -0,0 +1,9 @@
+-- keyset pagination walks (user_id, <sort column>, id)
+CREATE INDEX idx_products_user_created ON products(user_id, created_at, id);
+CREATE INDEX idx_products_user_updated ON products(user_id, updated_at, id);
+CREATE INDEX idx_products_user_price ON products(user_id, price, id);
+CREATE INDEX idx_products_user_stock ON products(user_id, stock, id);
+CREATE INDEX idx_products_user_name ON products(user_id, name, id);
+
+-- name prefix search: lower(name) LIKE 'abc%'
+CREATE INDEX idx_products_user_lower_name ON products(user_id, lower(name) text_pattern_ops);
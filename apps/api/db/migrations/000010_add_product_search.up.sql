@@ -0,0 +1,8 @@
+-- name matches weigh more than description matches in ts_rank
+ALTER TABLE products
+    ADD COLUMN search_vector TSVECTOR NOT NULL GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
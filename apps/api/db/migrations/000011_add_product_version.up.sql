@@ -0,0 +1,2 @@
+-- bumped on every write; the ETag of a product is derived from it
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+-- one row per Idempotency-Key a user has sent. status_code stays NULL
+-- while the first request is still being handled.
+CREATE TABLE idempotency_keys (
+    user_id           UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key               TEXT NOT NULL,
+    fingerprint       TEXT NOT NULL,
+    status_code       INTEGER,
+    response_headers  JSONB NOT NULL DEFAULT '{}',
+    response_body     BYTEA,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at        TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
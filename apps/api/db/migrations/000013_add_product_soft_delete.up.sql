@@ -0,0 +1,5 @@
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- the trash listing and the purge job only ever look at deleted rows
+CREATE INDEX idx_products_user_deleted_at ON products(user_id, deleted_at DESC, id) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_products_deleted_at ON products(deleted_at) WHERE deleted_at IS NOT NULL;
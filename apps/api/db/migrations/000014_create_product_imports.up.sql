@@ -0,0 +1,16 @@
+-- one row per bulk import, so large ones can run in the background
+-- and be polled. errors holds the per-row report.
+CREATE TABLE product_imports (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id        UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mode           TEXT NOT NULL CHECK (mode IN ('atomic', 'best_effort')),
+    status         TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'failed')),
+    total_rows     INTEGER NOT NULL,
+    inserted_rows  INTEGER NOT NULL DEFAULT 0,
+    failed_rows    INTEGER NOT NULL DEFAULT 0,
+    errors         JSONB NOT NULL DEFAULT '[]',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_product_imports_user_id ON product_imports(user_id, created_at DESC);
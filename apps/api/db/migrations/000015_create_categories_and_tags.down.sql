@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS product_tags;
+DROP TABLE IF EXISTS product_categories;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS categories;
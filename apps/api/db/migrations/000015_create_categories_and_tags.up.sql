@@ -0,0 +1,42 @@
+-- categories form a tree per user. A row can't be its own parent;
+-- longer cycles are rejected by the service, which moves categories
+-- while holding a per-user lock.
+CREATE TABLE categories (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id   UUID REFERENCES categories(id),
+    name        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (parent_id <> id)
+);
+
+-- sibling names are unique; top-level categories all share the NULL parent
+CREATE UNIQUE INDEX idx_categories_sibling_name
+    ON categories(user_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), lower(name));
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+CREATE TABLE tags (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_tags_user_name ON tags(user_id, lower(name));
+
+CREATE TABLE product_categories (
+    product_id   UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    category_id  UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX idx_product_categories_category_id ON product_categories(category_id);
+
+CREATE TABLE product_tags (
+    product_id  UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tag_id      UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, tag_id)
+);
+
+CREATE INDEX idx_product_tags_tag_id ON product_tags(tag_id);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS product_images_queue_blobs ON product_images;
+DROP FUNCTION IF EXISTS queue_product_image_blobs();
+DROP TABLE IF EXISTS blob_deletions;
+DROP TABLE IF EXISTS product_images;
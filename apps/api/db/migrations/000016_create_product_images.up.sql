@@ -0,0 +1,35 @@
+-- image files live in blob storage; rows point at them by key
+CREATE TABLE product_images (
+    id             UUID PRIMARY KEY,
+    product_id     UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    content_type   TEXT NOT NULL,
+    size_bytes     BIGINT NOT NULL,
+    width          INTEGER NOT NULL,
+    height         INTEGER NOT NULL,
+    storage_key    TEXT NOT NULL,
+    thumbnail_key  TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_images_product_id ON product_images(product_id, created_at);
+
+-- blobs whose rows are gone, waiting for the cleanup job. A trigger
+-- fills it, so rows removed by cascades (a purged product, a deleted
+-- user) don't leave files behind.
+CREATE TABLE blob_deletions (
+    key         TEXT PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE FUNCTION queue_product_image_blobs() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO blob_deletions (key)
+    VALUES (OLD.storage_key), (OLD.thumbnail_key)
+    ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER product_images_queue_blobs
+    AFTER DELETE ON product_images
+    FOR EACH ROW EXECUTE FUNCTION queue_product_image_blobs();
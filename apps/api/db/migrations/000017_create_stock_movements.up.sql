@@ -0,0 +1,34 @@
+-- every change to a product's stock, oldest first. products.stock is
+-- kept in step with it, in the same transaction as each movement.
+CREATE TABLE stock_movements (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id   UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    -- who made the change
+    user_id      UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason       TEXT NOT NULL CHECK (reason IN ('restock', 'sale', 'adjustment', 'return')),
+    delta        INTEGER NOT NULL CHECK (delta <> 0),
+    stock_after  INTEGER NOT NULL CHECK (stock_after >= 0),
+    note         TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_movements_product_id ON stock_movements(product_id, created_at DESC, id DESC);
+
+-- the ledger is append-only; rows only go away with their product
+CREATE FUNCTION reject_stock_movement_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION reject_stock_movement_update();
+
+ALTER TABLE products ADD CONSTRAINT products_stock_non_negative CHECK (stock >= 0);
+
+-- open the ledger with what's in stock today, so it sums to products.stock
+INSERT INTO stock_movements (product_id, user_id, reason, delta, stock_after, note)
+SELECT id, user_id, 'adjustment', stock, stock, 'opening balance'
+FROM products
+WHERE stock > 0;
@@ -0,0 +1,32 @@
+-- status moves pending -> paid -> shipped -> delivered; pending and
+-- paid orders can be cancelled instead, which puts their stock back
+CREATE TABLE orders (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status        TEXT NOT NULL DEFAULT 'pending'
+                  CHECK (status IN ('pending', 'paid', 'shipped', 'delivered', 'cancelled')),
+    total         NUMERIC(12, 2) NOT NULL DEFAULT 0.00,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    paid_at       TIMESTAMPTZ,
+    shipped_at    TIMESTAMPTZ,
+    delivered_at  TIMESTAMPTZ,
+    cancelled_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_orders_user_id ON orders(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_orders_status ON orders(status, created_at DESC);
+
+-- name and price are copied from the product at checkout, so the order
+-- reads the same after the product changes or is purged
+CREATE TABLE order_items (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id      UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id    UUID REFERENCES products(id) ON DELETE SET NULL,
+    product_name  TEXT NOT NULL,
+    unit_price    NUMERIC(10, 2) NOT NULL,
+    quantity      INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX idx_order_items_product_id ON order_items(product_id);
@@ -0,0 +1,24 @@
+-- one cart per user; anonymous carts have no user and are found
+-- through a signed cookie until they're merged or go stale
+CREATE TABLE carts (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_carts_anonymous_updated_at ON carts(updated_at) WHERE user_id IS NULL;
+
+-- unit_price is the price when the item was last added, so reading the
+-- cart can tell the shopper it has changed since
+CREATE TABLE cart_items (
+    cart_id     UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id  UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity    INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price  NUMERIC(10, 2) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (cart_id, product_id)
+);
+
+CREATE INDEX idx_cart_items_product_id ON cart_items(product_id);
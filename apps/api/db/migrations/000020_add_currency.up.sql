@@ -0,0 +1,10 @@
+-- ISO 4217 codes. Prices are only ever compared or added up within
+-- one currency; which codes are valid is checked by the API.
+ALTER TABLE products
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD'
+    CONSTRAINT products_currency_format CHECK (currency ~ '^[A-Z]{3}$');
+
+-- every line of an order is in the order's currency
+ALTER TABLE orders
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD'
+    CONSTRAINT orders_currency_format CHECK (currency ~ '^[A-Z]{3}$');
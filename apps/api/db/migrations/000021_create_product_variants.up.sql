@@ -0,0 +1,21 @@
+-- sellable versions of a product, such as a shirt in size M and red.
+-- price and stock override the product's when set; NULL means the
+-- variant takes the product's. user_id is the product's owner, copied
+-- here so SKUs can be unique per user.
+CREATE TABLE product_variants (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id  UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sku         TEXT NOT NULL,
+    -- option name to value, e.g. {"color": "red", "size": "M"}
+    options     JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(options) = 'object'),
+    price       NUMERIC(10, 2) CHECK (price >= 0),
+    stock       INTEGER CHECK (stock >= 0),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_product_variants_user_sku ON product_variants(user_id, lower(sku));
+-- no two variants of a product share the same options
+CREATE UNIQUE INDEX idx_product_variants_product_options ON product_variants(product_id, options);
+CREATE INDEX idx_product_variants_product_id ON product_variants(product_id, created_at);
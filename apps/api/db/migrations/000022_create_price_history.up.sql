@@ -0,0 +1,42 @@
+-- price changes queued for a future time, e.g. the start and end of a
+-- sale. The scheduler applies each one exactly once: it marks the row
+-- in the same transaction as it changes the price. currency is the
+-- product's when the change was scheduled; if the product has moved to
+-- another currency by then, the change is skipped.
+CREATE TABLE scheduled_price_changes (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id    UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    -- who scheduled it
+    user_id       UUID REFERENCES users(id) ON DELETE SET NULL,
+    price         NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+    currency      CHAR(3) NOT NULL,
+    effective_at  TIMESTAMPTZ NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'applied', 'skipped')),
+    -- when the scheduler applied or skipped it
+    processed_at  TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scheduled_price_changes_due ON scheduled_price_changes(effective_at) WHERE status = 'pending';
+CREATE INDEX idx_scheduled_price_changes_product_id ON scheduled_price_changes(product_id, effective_at);
+
+-- every price a product has had. Rows are written in the same
+-- transaction as the change, so the newest is always products.price.
+CREATE TABLE price_history (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id           UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    -- who made the change, or scheduled it
+    user_id              UUID REFERENCES users(id) ON DELETE SET NULL,
+    price                NUMERIC(10, 2) NOT NULL,
+    currency             CHAR(3) NOT NULL,
+    reason               TEXT NOT NULL CHECK (reason IN ('initial', 'update', 'scheduled')),
+    scheduled_change_id  UUID REFERENCES scheduled_price_changes(id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_price_history_product_id ON price_history(product_id, created_at DESC, id DESC);
+
+-- open the history with today's prices
+INSERT INTO price_history (product_id, user_id, price, currency, reason)
+SELECT id, user_id, price, currency, 'initial'
+FROM products;
@@ -0,0 +1,58 @@
+-- store-wide discounts. Promotions with a code are coupons and only
+-- apply when the shopper enters it; those without apply by themselves.
+-- value is a percentage off for percentage promotions and an amount
+-- off, in currency, for fixed ones. min_order is also in currency.
+CREATE TABLE promotions (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name               TEXT NOT NULL,
+    code               TEXT,
+    kind               TEXT NOT NULL CHECK (kind IN ('percentage', 'fixed')),
+    value              NUMERIC(12, 2) NOT NULL CHECK (value > 0),
+    currency           CHAR(3),
+    min_order          NUMERIC(12, 2) CHECK (min_order > 0),
+    -- NULL means unlimited
+    max_uses           INTEGER CHECK (max_uses > 0),
+    max_uses_per_user  INTEGER CHECK (max_uses_per_user > 0),
+    -- NULL means open-ended
+    starts_at          TIMESTAMPTZ,
+    ends_at            TIMESTAMPTZ,
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by         UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (kind <> 'percentage' OR value <= 100),
+    CHECK (kind <> 'fixed' OR currency IS NOT NULL),
+    CHECK (min_order IS NULL OR currency IS NOT NULL),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE UNIQUE INDEX idx_promotions_code ON promotions(lower(code)) WHERE code IS NOT NULL;
+CREATE INDEX idx_promotions_automatic ON promotions(created_at) WHERE code IS NULL AND active;
+
+-- a promotion with products only discounts those; one without
+-- discounts the whole basket
+CREATE TABLE promotion_products (
+    promotion_id  UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    product_id    UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (promotion_id, product_id)
+);
+
+-- discounts orders actually got. Usage limits count these rows;
+-- cancelling an order deletes its redemptions.
+CREATE TABLE promotion_redemptions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promotion_id  UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id      UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount        NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX idx_promotion_redemptions_user ON promotion_redemptions(promotion_id, user_id);
+CREATE INDEX idx_promotion_redemptions_order_id ON promotion_redemptions(order_id);
+
+-- total is now subtotal less discount
+ALTER TABLE orders ADD COLUMN subtotal NUMERIC(12, 2) NOT NULL DEFAULT 0.00;
+ALTER TABLE orders ADD COLUMN discount NUMERIC(12, 2) NOT NULL DEFAULT 0.00 CHECK (discount >= 0);
+UPDATE orders SET subtotal = total;
@@ -0,0 +1,27 @@
+-- one review per user per product. New and edited reviews wait in
+-- moderation; only approved ones are shown to others and count toward
+-- the product's rating.
+CREATE TABLE reviews (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id    UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating        SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body          TEXT NOT NULL DEFAULT '',
+    status        TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    moderated_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at  TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, user_id)
+);
+
+CREATE INDEX idx_reviews_product_created ON reviews(product_id, created_at DESC) WHERE status = 'approved';
+CREATE INDEX idx_reviews_status_created ON reviews(status, created_at);
+CREATE INDEX idx_reviews_user_id ON reviews(user_id);
+
+-- running totals over approved reviews, moved in the same transaction
+-- as a review enters or leaves approved, so reads never aggregate
+ALTER TABLE products ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0 CHECK (rating_count >= 0);
+ALTER TABLE products ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0 CHECK (rating_sum >= 0);
+ALTER TABLE products ADD COLUMN rating_average NUMERIC(3, 2)
+    GENERATED ALWAYS AS (round(rating_sum::numeric / NULLIF(rating_count, 0), 2)) STORED;
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS catalog_products;
+DROP INDEX IF EXISTS idx_products_catalog;
+ALTER TABLE products DROP COLUMN IF EXISTS is_published;
@@ -0,0 +1,16 @@
+-- products stay private to their owner until published
+ALTER TABLE products ADD COLUMN is_published BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_products_catalog ON products(created_at DESC, id DESC)
+    WHERE is_published AND deleted_at IS NULL;
+
+-- all anonymous visitors may see of a product: published ones out of
+-- the trash, without the owner or the exact stock. version is only
+-- there for ETags.
+CREATE VIEW catalog_products AS
+SELECT
+    id, name, description, price, currency,
+    stock > 0 AS in_stock,
+    rating_count, rating_average, created_at, updated_at, version
+FROM products
+WHERE is_published AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- without variant_id these would read as movements of the product
+DELETE FROM stock_movements WHERE variant_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_stock_movements_variant_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS variant_id;
@@ -0,0 +1,14 @@
+-- variants with stock of their own keep a ledger too, in the same
+-- table: rows with a variant_id track that variant's stock, the rest
+-- the product's. product_variants.stock is kept in step with it.
+ALTER TABLE stock_movements
+    ADD COLUMN variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_stock_movements_variant_id ON stock_movements(variant_id, created_at DESC, id DESC)
+    WHERE variant_id IS NOT NULL;
+
+-- open each variant's ledger with what it has in stock today
+INSERT INTO stock_movements (product_id, variant_id, user_id, reason, delta, stock_after, note)
+SELECT product_id, id, user_id, 'adjustment', stock, stock, 'opening balance'
+FROM product_variants
+WHERE stock > 0;
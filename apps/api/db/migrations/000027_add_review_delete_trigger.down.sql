@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS reviews_unrate_on_delete ON reviews;
+DROP FUNCTION IF EXISTS unrate_deleted_review();
@@ -0,0 +1,33 @@
+-- Reviews also go when their author's account is deleted, by cascade,
+-- without passing through the service. Deleting an approved review,
+-- however it happens, takes it out of the product's rating totals here.
+CREATE FUNCTION unrate_deleted_review() RETURNS trigger AS $$
+BEGIN
+    IF OLD.status = 'approved' THEN
+        UPDATE products
+        SET rating_count = rating_count - 1,
+            rating_sum   = rating_sum - OLD.rating,
+            version      = version + 1
+        WHERE id = OLD.product_id;
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reviews_unrate_on_delete
+    AFTER DELETE ON reviews
+    FOR EACH ROW EXECUTE FUNCTION unrate_deleted_review();
+
+-- fix totals that still count reviews deleted with their author
+UPDATE products p
+SET rating_count = t.count,
+    rating_sum   = t.sum,
+    version      = p.version + 1
+FROM (
+    SELECT p.id, COUNT(r.id) AS count, COALESCE(SUM(r.rating), 0) AS sum
+    FROM products p
+    LEFT JOIN reviews r ON r.product_id = p.id AND r.status = 'approved'
+    GROUP BY p.id
+) t
+WHERE t.id = p.id
+  AND (p.rating_count, p.rating_sum) IS DISTINCT FROM (t.count::int, t.sum::int);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION reject_stock_movement_update();
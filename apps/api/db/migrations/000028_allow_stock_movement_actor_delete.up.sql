@@ -0,0 +1,21 @@
+-- Deleting a user nulls user_id on their ledger rows (ON DELETE SET
+-- NULL), and the append-only trigger rejected that update, so nobody
+-- who appears in the ledger could be deleted. Let through updates that
+-- only clear user_id; anything else is still refused.
+DROP TRIGGER stock_movements_append_only ON stock_movements;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE ON stock_movements
+    FOR EACH ROW
+    WHEN (NOT (
+        OLD.user_id IS NOT NULL AND NEW.user_id IS NULL
+        AND NEW.id = OLD.id
+        AND NEW.product_id = OLD.product_id
+        AND NEW.variant_id IS NOT DISTINCT FROM OLD.variant_id
+        AND NEW.reason = OLD.reason
+        AND NEW.delta = OLD.delta
+        AND NEW.stock_after = OLD.stock_after
+        AND NEW.note IS NOT DISTINCT FROM OLD.note
+        AND NEW.created_at = OLD.created_at
+    ))
+    EXECUTE FUNCTION reject_stock_movement_update();
@@ -0,0 +1,9 @@
+-- name: CreateAdminAuditLog :exec
+INSERT INTO admin_audit_log (admin_id, target_user_id, action, details)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListAdminAuditLogsByTarget :many
+SELECT * FROM admin_audit_log
+WHERE target_user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
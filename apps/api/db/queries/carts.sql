@@ -0,0 +1,73 @@
+-- name: CreateAnonymousCart :one
+INSERT INTO carts DEFAULT VALUES
+RETURNING *;
+
+-- name: GetAnonymousCart :one
+SELECT * FROM carts
+WHERE id = $1 AND user_id IS NULL
+LIMIT 1;
+
+-- name: GetUserCart :one
+SELECT * FROM carts
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: UpsertUserCart :one
+INSERT INTO carts (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
+RETURNING *;
+
+-- name: TouchCart :exec
+UPDATE carts SET updated_at = NOW() WHERE id = $1;
+
+-- name: DeleteCart :exec
+DELETE FROM carts WHERE id = $1;
+
+-- name: DeleteStaleAnonymousCarts :execrows
+DELETE FROM carts
+WHERE user_id IS NULL AND updated_at < $1;
+
+-- name: ListCartItems :many
+-- The items with the product as it is now. Trashed and unpublished
+-- products are still listed, so the shopper sees why they dropped out.
+SELECT ci.product_id, ci.quantity, ci.unit_price AS added_price,
+       p.name, p.price, p.currency, p.stock, p.deleted_at,
+       p.user_id AS seller_id, p.is_published
+FROM cart_items ci
+JOIN products p ON p.id = ci.product_id
+WHERE ci.cart_id = $1
+ORDER BY ci.created_at, ci.product_id;
+
+-- name: AddCartItem :one
+-- adding a product that's already in the cart adds to its quantity,
+-- up to the 10000 a single request may ask for
+INSERT INTO cart_items (cart_id, product_id, quantity, unit_price)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cart_id, product_id) DO UPDATE
+SET quantity   = LEAST(cart_items.quantity + EXCLUDED.quantity, 10000),
+    unit_price = EXCLUDED.unit_price,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: SetCartItemQuantity :one
+UPDATE cart_items
+SET quantity = $3, updated_at = NOW()
+WHERE cart_id = $1 AND product_id = $2
+RETURNING *;
+
+-- name: RemoveCartItem :execrows
+DELETE FROM cart_items
+WHERE cart_id = $1 AND product_id = $2;
+
+-- name: MergeCartItems :exec
+-- Moves every item of from_cart into into_cart, adding quantities
+-- (capped like AddCartItem) where both have the same product.
+INSERT INTO cart_items (cart_id, product_id, quantity, unit_price)
+SELECT sqlc.arg('into_cart')::uuid, product_id, quantity, unit_price
+FROM cart_items
+WHERE cart_id = sqlc.arg('from_cart')
+ON CONFLICT (cart_id, product_id) DO UPDATE
+SET quantity   = LEAST(cart_items.quantity + EXCLUDED.quantity, 10000),
+    unit_price = EXCLUDED.unit_price,
+    updated_at = NOW();
@@ -0,0 +1,13 @@
+-- name: ListCatalogProducts :many
+-- newest first; the view only has published products
+SELECT * FROM catalog_products
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountCatalogProducts :one
+SELECT COUNT(*) FROM catalog_products;
+
+-- name: GetCatalogProduct :one
+SELECT * FROM catalog_products
+WHERE id = $1
+LIMIT 1;
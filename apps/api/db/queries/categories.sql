@@ -0,0 +1,39 @@
+-- name: CreateCategory :one
+INSERT INTO categories (user_id, parent_id, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetCategory :one
+SELECT * FROM categories
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: ListCategories :many
+SELECT * FROM categories
+WHERE user_id = $1
+ORDER BY lower(name), id;
+
+-- name: UpdateCategory :one
+UPDATE categories
+SET name       = $3,
+    parent_id  = $4,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories
+WHERE id = $1 AND user_id = $2;
+
+-- name: IsCategoryInSubtree :one
+-- reports whether category_id is root_id or one of its descendants
+WITH RECURSIVE subtree AS (
+    SELECT id FROM categories WHERE id = sqlc.arg('root_id')
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE id = sqlc.arg('category_id'));
+
+-- name: LockUserCategories :exec
+-- serialises changes to one user's category tree until the transaction ends
+SELECT pg_advisory_xact_lock(hashtextextended('categories:' || sqlc.arg('user_id')::text, 0));
@@ -0,0 +1,14 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: ConsumeEmailVerificationToken :one
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE jti = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING user_id;
+
+-- name: InvalidateUserEmailVerificationTokens :exec
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
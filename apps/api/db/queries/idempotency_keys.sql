@@ -0,0 +1,32 @@
+-- name: ClaimIdempotencyKey :execrows
+-- Takes the key for a new request. An expired key is taken over;
+-- a live one, finished or still running, affects no rows.
+INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, key) DO UPDATE
+SET fingerprint      = EXCLUDED.fingerprint,
+    status_code      = NULL,
+    response_headers = '{}',
+    response_body    = NULL,
+    created_at       = NOW(),
+    expires_at       = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < NOW();
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code      = $3,
+    response_headers = $4,
+    response_body    = $5
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE expires_at < NOW();
@@ -0,0 +1,69 @@
+-- name: CreateOrder :one
+INSERT INTO orders (user_id, currency)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: CreateOrderItem :one
+INSERT INTO order_items (order_id, product_id, product_name, unit_price, quantity)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: SetOrderTotal :one
+-- sums the order's items in NUMERIC, so no rounding creeps in, and
+-- takes off the discount
+WITH items AS (
+    SELECT COALESCE(SUM(unit_price * quantity), 0) AS subtotal
+    FROM order_items
+    WHERE order_id = sqlc.arg('id')
+)
+UPDATE orders
+SET subtotal   = items.subtotal,
+    discount   = sqlc.arg('discount'),
+    total      = items.subtotal - sqlc.arg('discount'),
+    updated_at = NOW()
+FROM items
+WHERE orders.id = sqlc.arg('id')
+RETURNING orders.*;
+
+-- name: GetOrder :one
+SELECT * FROM orders
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+LIMIT 1;
+
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+FOR UPDATE;
+
+-- name: ListOrders :many
+SELECT * FROM orders
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountOrders :one
+SELECT COUNT(*) FROM orders
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'));
+
+-- name: ListOrderItems :many
+SELECT * FROM order_items
+WHERE order_id = $1
+ORDER BY product_name, id;
+
+-- name: UpdateOrderStatus :one
+-- Only moves the order if it's still in from_status. Stamps the time
+-- the new status was reached.
+UPDATE orders
+SET status       = sqlc.arg('status'),
+    updated_at   = NOW(),
+    paid_at      = CASE WHEN sqlc.arg('status')::text = 'paid' THEN NOW() ELSE paid_at END,
+    shipped_at   = CASE WHEN sqlc.arg('status')::text = 'shipped' THEN NOW() ELSE shipped_at END,
+    delivered_at = CASE WHEN sqlc.arg('status')::text = 'delivered' THEN NOW() ELSE delivered_at END,
+    cancelled_at = CASE WHEN sqlc.arg('status')::text = 'cancelled' THEN NOW() ELSE cancelled_at END
+WHERE id = sqlc.arg('id')
+  AND status = sqlc.arg('from_status')
+RETURNING *;
@@ -0,0 +1,43 @@
+-- name: CreatePriceHistoryEntry :one
+INSERT INTO price_history (product_id, user_id, price, currency, reason, scheduled_change_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListPriceHistory :many
+SELECT * FROM price_history
+WHERE product_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountPriceHistory :one
+SELECT COUNT(*) FROM price_history
+WHERE product_id = $1;
+
+-- name: CreateScheduledPriceChange :one
+INSERT INTO scheduled_price_changes (product_id, user_id, price, currency, effective_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListScheduledPriceChanges :many
+SELECT * FROM scheduled_price_changes
+WHERE product_id = $1
+ORDER BY effective_at, id;
+
+-- name: DeleteScheduledPriceChange :execrows
+-- only changes that haven't happened yet can be called off
+DELETE FROM scheduled_price_changes
+WHERE id = $1 AND product_id = $2 AND status = 'pending';
+
+-- name: NextDueScheduledPriceChange :one
+-- SKIP LOCKED lets several API instances run the scheduler at once
+-- without waiting on, or repeating, each other's work
+SELECT * FROM scheduled_price_changes
+WHERE status = 'pending' AND effective_at <= NOW()
+ORDER BY effective_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkScheduledPriceChange :exec
+UPDATE scheduled_price_changes
+SET status = $2, processed_at = NOW()
+WHERE id = $1;
@@ -1,29 +1,173 @@
 -- name: CreateProduct :one
-INSERT INTO products (user_id, name, description, price, stock)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING *;
+-- Any starting stock is recorded in the ledger, and the price in the
+-- price history, in the same statement.
+WITH product AS (
+    INSERT INTO products (user_id, name, description, price, currency, stock, is_published)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING *
+), opening AS (
+    INSERT INTO stock_movements (product_id, user_id, reason, delta, stock_after, note)
+    SELECT id, user_id, 'adjustment', stock, stock, 'initial stock'
+    FROM product
+    WHERE stock > 0
+), first_price AS (
+    INSERT INTO price_history (product_id, user_id, price, currency, reason)
+    SELECT id, user_id, price, currency, 'initial'
+    FROM product
+)
+SELECT * FROM product;
 
 -- name: GetProductByID :one
 SELECT * FROM products
-WHERE id = $1 AND user_id = $2
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NULL
 LIMIT 1;
 
--- name: ListProductsByUser :many
+-- name: GetProductForUpdate :one
+-- like GetProductByID, but holds the row until the transaction ends
 SELECT * FROM products
-WHERE user_id = $1
-ORDER BY created_at DESC;
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NULL
+FOR NO KEY UPDATE;
 
 -- name: UpdateProduct :one
+-- NULL arguments leave their column alone. description is nullable
+-- itself, so clearing it goes through clear_description. A non-NULL
+-- expected_version makes the update conditional on the row's version.
+UPDATE products
+SET
+    name         = COALESCE(sqlc.narg('name'), name),
+    description  = CASE
+                       WHEN sqlc.arg('clear_description')::boolean THEN NULL
+                       ELSE COALESCE(sqlc.narg('description'), description)
+                   END,
+    price        = COALESCE(sqlc.narg('price'), price),
+    currency     = COALESCE(sqlc.narg('currency'), currency),
+    stock        = COALESCE(sqlc.narg('stock'), stock),
+    is_published = COALESCE(sqlc.narg('is_published'), is_published),
+    version      = version + 1,
+    updated_at   = NOW()
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('expected_version')::integer IS NULL OR version = sqlc.narg('expected_version'))
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: AdjustProductStock :one
+-- Applies a stock delta unless it would take stock below zero, in
+-- which case no row comes back. The caller records the movement in
+-- the same transaction.
+UPDATE products
+SET stock      = stock + sqlc.arg('delta'),
+    version    = version + 1,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NULL
+  AND stock + sqlc.arg('delta') >= 0
+RETURNING *;
+
+-- name: ReleaseProductStock :one
+-- Puts stock back, e.g. from a cancelled order. Unlike
+-- AdjustProductStock it also reaches products in the trash.
+UPDATE products
+SET stock      = stock + sqlc.arg('quantity'),
+    version    = version + 1,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: SetScheduledProductPrice :one
+-- Applies a scheduled price, as long as the product is still in the
+-- currency it was scheduled in; otherwise no row comes back. Products
+-- in the trash change too, so they're current if restored.
+UPDATE products
+SET price      = sqlc.arg('price'),
+    version    = version + 1,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+  AND currency = sqlc.arg('currency')
+RETURNING *;
+
+-- name: AdjustProductRating :exec
+-- Moves the running rating totals as an approved review is added,
+-- re-rated or withdrawn. A rating isn't an edit, so updated_at stays,
+-- but version moves so cached copies go stale. Products in the trash
+-- change too.
+UPDATE products
+SET rating_count = rating_count + sqlc.arg('count_delta'),
+    rating_sum   = rating_sum + sqlc.arg('sum_delta'),
+    version      = version + 1
+WHERE id = sqlc.arg('id');
+
+-- name: DeleteProduct :execrows
+-- Moves the product to the trash. PurgeProduct deletes it for real.
 UPDATE products
 SET
-    name        = COALESCE($1, name),
-    description = COALESCE($2, description),
-    price       = COALESCE($3, price),
-    stock       = COALESCE($4, stock),
-    updated_at  = NOW()
-WHERE id = $5 AND user_id = $6
+    deleted_at = NOW(),
+    version    = version + 1
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('expected_version')::integer IS NULL OR version = sqlc.narg('expected_version'))
+  AND deleted_at IS NULL;
+
+-- name: RestoreProduct :one
+UPDATE products
+SET
+    deleted_at = NULL,
+    version    = version + 1
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NOT NULL
 RETURNING *;
 
--- name: DeleteProduct :exec
+-- name: PurgeProduct :execrows
+-- Only trashed products can be purged
 DELETE FROM products
-WHERE id = $1 AND user_id = $2;
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NOT NULL;
+
+-- name: ListTrashedProducts :many
+SELECT * FROM products
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountTrashedProducts :one
+SELECT COUNT(*) FROM products
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NOT NULL;
+
+-- name: PurgeTrashedProducts :execrows
+-- Deletes everything that has been in the trash since before cutoff
+DELETE FROM products
+WHERE deleted_at < sqlc.arg('cutoff');
+
+-- name: SearchProducts :many
+SELECT
+    id, user_id, name, description, price, stock, created_at, updated_at, version, currency,
+    ts_rank(search_vector, to_tsquery('english', sqlc.arg('query')))::real AS rank,
+    -- the text is HTML-escaped before highlighting, so the only markup
+    -- in a headline is the <mark> tags
+    ts_headline(
+        'english',
+        replace(replace(replace(concat_ws(' ', name, description), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        to_tsquery('english', sqlc.arg('query')),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5'
+    ) AS headline
+FROM products
+WHERE search_vector @@ to_tsquery('english', sqlc.arg('query'))
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NULL
+ORDER BY rank DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountSearchProducts :one
+SELECT COUNT(*) FROM products
+WHERE search_vector @@ to_tsquery('english', sqlc.arg('query'))
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND deleted_at IS NULL;
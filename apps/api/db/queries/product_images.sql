@@ -0,0 +1,32 @@
+-- name: CreateProductImage :one
+INSERT INTO product_images (id, product_id, content_type, size_bytes, width, height, storage_key, thumbnail_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetProductImage :one
+SELECT * FROM product_images
+WHERE id = $1 AND product_id = $2
+LIMIT 1;
+
+-- name: ListProductImages :many
+SELECT * FROM product_images
+WHERE product_id = $1
+ORDER BY created_at, id;
+
+-- name: CountProductImages :one
+SELECT COUNT(*) FROM product_images
+WHERE product_id = $1;
+
+-- name: DeleteProductImage :execrows
+-- the trigger queues its blobs for deletion
+DELETE FROM product_images
+WHERE id = $1 AND product_id = $2;
+
+-- name: ListBlobDeletions :many
+SELECT key FROM blob_deletions
+ORDER BY created_at
+LIMIT $1;
+
+-- name: DeleteBlobDeletion :exec
+DELETE FROM blob_deletions
+WHERE key = $1;
@@ -0,0 +1,33 @@
+-- name: CreateProductImport :one
+INSERT INTO product_imports (user_id, mode, total_rows)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetProductImport :one
+SELECT * FROM product_imports
+WHERE id = sqlc.arg('id')
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+LIMIT 1;
+
+-- name: UpdateProductImportProgress :exec
+UPDATE product_imports
+SET inserted_rows = $2,
+    failed_rows   = $3
+WHERE id = $1;
+
+-- name: FinishProductImport :exec
+UPDATE product_imports
+SET status        = $2,
+    inserted_rows = $3,
+    failed_rows   = $4,
+    errors        = $5,
+    finished_at   = NOW()
+WHERE id = $1;
+
+-- name: FailStaleProductImports :execrows
+-- imports still running past their deadline died with their process
+UPDATE product_imports
+SET status      = 'failed',
+    errors      = errors || '[{"line": 0, "message": "import was interrupted"}]'::jsonb,
+    finished_at = NOW()
+WHERE status = 'running' AND created_at < $1;
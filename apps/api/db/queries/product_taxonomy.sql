@@ -0,0 +1,31 @@
+-- name: ListProductCategories :many
+SELECT c.* FROM categories c
+JOIN product_categories pc ON pc.category_id = c.id
+WHERE pc.product_id = $1
+ORDER BY lower(c.name), c.id;
+
+-- name: ClearProductCategories :exec
+DELETE FROM product_categories
+WHERE product_id = $1;
+
+-- name: AddProductCategory :execrows
+-- only links categories that belong to user_id
+INSERT INTO product_categories (product_id, category_id)
+SELECT sqlc.arg('product_id'), id FROM categories
+WHERE id = sqlc.arg('category_id') AND user_id = sqlc.arg('user_id')
+ON CONFLICT DO NOTHING;
+
+-- name: ListProductTags :many
+SELECT t.* FROM tags t
+JOIN product_tags pt ON pt.tag_id = t.id
+WHERE pt.product_id = $1
+ORDER BY lower(t.name);
+
+-- name: ClearProductTags :exec
+DELETE FROM product_tags
+WHERE product_id = $1;
+
+-- name: AddProductTag :exec
+INSERT INTO product_tags (product_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
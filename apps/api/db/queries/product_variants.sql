@@ -0,0 +1,47 @@
+-- name: CreateProductVariant :one
+INSERT INTO product_variants (product_id, user_id, sku, options, price, stock)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetProductVariant :one
+SELECT * FROM product_variants
+WHERE id = $1 AND product_id = $2
+LIMIT 1;
+
+-- name: GetProductVariantForUpdate :one
+-- like GetProductVariant, but holds the row until the transaction ends
+SELECT * FROM product_variants
+WHERE id = $1 AND product_id = $2
+FOR NO KEY UPDATE;
+
+-- name: ListProductVariants :many
+SELECT * FROM product_variants
+WHERE product_id = $1
+ORDER BY created_at, id;
+
+-- name: CountProductVariants :one
+SELECT COUNT(*) FROM product_variants
+WHERE product_id = $1;
+
+-- name: UpdateProductVariant :one
+-- replaces every field; a NULL price or stock goes back to the product's
+UPDATE product_variants
+SET sku = $3, options = $4, price = $5, stock = $6, updated_at = NOW()
+WHERE id = $1 AND product_id = $2
+RETURNING *;
+
+-- name: AdjustVariantStock :one
+-- Applies a stock delta to a variant with stock of its own, unless it
+-- would take it below zero; otherwise no row comes back. The caller
+-- records the movement in the same transaction.
+UPDATE product_variants
+SET stock      = stock + sqlc.arg('delta'),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+  AND product_id = sqlc.arg('product_id')
+  AND stock + sqlc.arg('delta') >= 0
+RETURNING *;
+
+-- name: DeleteProductVariant :execrows
+DELETE FROM product_variants
+WHERE id = $1 AND product_id = $2;
@@ -0,0 +1,97 @@
+-- name: CreatePromotion :one
+INSERT INTO promotions (
+    name, code, kind, value, currency, min_order,
+    max_uses, max_uses_per_user, starts_at, ends_at, active, created_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING *;
+
+-- name: GetPromotion :one
+SELECT * FROM promotions
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetPromotionByCode :one
+-- codes match ignoring case
+SELECT * FROM promotions
+WHERE lower(code) = lower(sqlc.arg('code'))
+LIMIT 1;
+
+-- name: LockPromotion :one
+-- holds the promotion while its usage is counted and a redemption
+-- recorded, so two checkouts can't both take its last use
+SELECT * FROM promotions
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListPromotions :many
+SELECT * FROM promotions
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountPromotions :one
+SELECT COUNT(*) FROM promotions;
+
+-- name: ListAutomaticPromotions :many
+-- active promotions without a code whose window includes now
+SELECT * FROM promotions
+WHERE code IS NULL
+  AND active
+  AND (starts_at IS NULL OR starts_at <= sqlc.arg('now'))
+  AND (ends_at IS NULL OR ends_at > sqlc.arg('now'))
+ORDER BY created_at, id;
+
+-- name: UpdatePromotion :one
+-- replaces every field
+UPDATE promotions
+SET name              = $2,
+    code              = $3,
+    kind              = $4,
+    value             = $5,
+    currency          = $6,
+    min_order         = $7,
+    max_uses          = $8,
+    max_uses_per_user = $9,
+    starts_at         = $10,
+    ends_at           = $11,
+    active            = $12,
+    updated_at        = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePromotion :execrows
+DELETE FROM promotions
+WHERE id = $1;
+
+-- name: ListPromotionProducts :many
+SELECT product_id FROM promotion_products
+WHERE promotion_id = $1
+ORDER BY product_id;
+
+-- name: ClearPromotionProducts :exec
+DELETE FROM promotion_products
+WHERE promotion_id = $1;
+
+-- name: AddPromotionProduct :execrows
+-- adds nothing if the product doesn't exist or is in the trash
+INSERT INTO promotion_products (promotion_id, product_id)
+SELECT sqlc.arg('promotion_id'), id
+FROM products
+WHERE id = sqlc.arg('product_id') AND deleted_at IS NULL;
+
+-- name: CountPromotionRedemptions :one
+SELECT COUNT(*) FROM promotion_redemptions
+WHERE promotion_id = $1;
+
+-- name: CountUserPromotionRedemptions :one
+SELECT COUNT(*) FROM promotion_redemptions
+WHERE promotion_id = $1 AND user_id = $2;
+
+-- name: CreatePromotionRedemption :exec
+INSERT INTO promotion_redemptions (promotion_id, user_id, order_id, amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteOrderRedemptions :exec
+-- frees up the uses a cancelled order took
+DELETE FROM promotion_redemptions
+WHERE order_id = $1;
@@ -0,0 +1,64 @@
+-- name: CreateReview :one
+INSERT INTO reviews (product_id, user_id, rating, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetReview :one
+SELECT * FROM reviews
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetReviewForUpdate :one
+-- holds the review while its status and the product's rating totals
+-- change together
+SELECT * FROM reviews
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListProductReviews :many
+-- approved reviews, plus the viewer's own whatever its status
+SELECT * FROM reviews
+WHERE product_id = sqlc.arg('product_id')
+  AND (status = 'approved' OR user_id = sqlc.narg('viewer_id'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountProductReviews :one
+SELECT COUNT(*) FROM reviews
+WHERE product_id = sqlc.arg('product_id')
+  AND (status = 'approved' OR user_id = sqlc.narg('viewer_id'));
+
+-- name: ListReviewsByStatus :many
+-- the moderation queue, oldest first; a NULL status lists every review
+SELECT * FROM reviews
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountReviewsByStatus :one
+SELECT COUNT(*) FROM reviews
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'));
+
+-- name: UpdateReview :one
+-- an edited review goes back to moderation
+UPDATE reviews
+SET rating       = $2,
+    body         = $3,
+    status       = 'pending',
+    moderated_by = NULL,
+    moderated_at = NULL,
+    updated_at   = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetReviewStatus :one
+UPDATE reviews
+SET status       = $2,
+    moderated_by = $3,
+    moderated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteReview :exec
+DELETE FROM reviews
+WHERE id = $1;
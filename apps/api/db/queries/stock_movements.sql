@@ -0,0 +1,26 @@
+-- name: CreateStockMovement :one
+-- variant_id is NULL for movements of the product's own stock
+INSERT INTO stock_movements (product_id, variant_id, user_id, reason, delta, stock_after, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListStockMovements :many
+-- the product's own stock; each variant's is listed separately
+SELECT * FROM stock_movements
+WHERE product_id = $1 AND variant_id IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountStockMovements :one
+SELECT COUNT(*) FROM stock_movements
+WHERE product_id = $1 AND variant_id IS NULL;
+
+-- name: ListVariantStockMovements :many
+SELECT * FROM stock_movements
+WHERE variant_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountVariantStockMovements :one
+SELECT COUNT(*) FROM stock_movements
+WHERE variant_id = $1;
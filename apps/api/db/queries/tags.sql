@@ -0,0 +1,26 @@
+-- name: CreateTag :one
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: UpsertTag :one
+-- returns the user's tag with this name, creating it if needed
+INSERT INTO tags (user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, lower(name)) DO UPDATE SET name = tags.name
+RETURNING *;
+
+-- name: ListTags :many
+SELECT * FROM tags
+WHERE user_id = $1
+ORDER BY lower(name);
+
+-- name: RenameTag :one
+UPDATE tags
+SET name = $3
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE id = $1 AND user_id = $2;
@@ -12,3 +12,62 @@ LIMIT 1;
 SELECT * FROM users
 WHERE id = $1
 LIMIT 1;
+
+-- name: GetUserSessionState :one
+SELECT token_version, disabled_at FROM users
+WHERE id = $1
+LIMIT 1;
+
+-- name: IncrementUserTokenVersion :one
+UPDATE users
+SET
+    token_version = token_version + 1,
+    updated_at    = NOW()
+WHERE id = $1
+RETURNING token_version;
+
+-- name: MarkUserEmailVerified :exec
+UPDATE users
+SET
+    email_verified_at = NOW(),
+    updated_at        = NOW()
+WHERE id = $1 AND email_verified_at IS NULL;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET
+    password   = $1,
+    updated_at = NOW()
+WHERE id = $2;
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at DESC, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET
+    role       = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: DisableUser :one
+UPDATE users
+SET
+    disabled_at = COALESCE(disabled_at, NOW()),
+    updated_at  = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: EnableUser :one
+UPDATE users
+SET
+    disabled_at = NULL,
+    updated_at  = NOW()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,27 @@
+// Package background has the jobs the server runs alongside requests
+package background
+
+import (
+	"context"
+	"time"
+)
+
+// Job is background work, like emptying the trash, that runs until
+// ctx is cancelled
+type Job func(ctx context.Context)
+
+// Every calls fn once per interval until ctx is cancelled. The first
+// call is one interval in.
+func Every(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
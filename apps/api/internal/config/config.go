@@ -1,6 +1,9 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
@@ -16,6 +19,11 @@ type Config struct {
 	Database DatabaseConfig `validate:"required"`
 	Auth     AuthConfig     `validate:"required"`
 	Email    EmailConfig    `validate:"required"`
+	Products ProductsConfig `validate:"required"`
+	Storage  StorageConfig  `validate:"required"`
+	Images   ImagesConfig   `validate:"required"`
+	Cart     CartConfig     `validate:"required"`
+	Catalog  CatalogConfig  `validate:"required"`
 }
 
 type PrimaryConfig struct {
@@ -28,6 +36,10 @@ type ServerConfig struct {
 	WriteTimeout  time.Duration `validate:"required"`
 	IdleTimeout   time.Duration `validate:"required"`
 	AllowedOrigin string        `validate:"required"`
+	// when set, product writes without an If-Match header get 428
+	RequireIfMatch bool
+	// how long a stored Idempotency-Key response can be replayed
+	IdempotencyKeyTTL time.Duration `validate:"required"`
 }
 
 type DatabaseConfig struct {
@@ -39,12 +51,75 @@ type DatabaseConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret string `validate:"required,min=32"`
+	JWTSecret       string        `validate:"required,min=32"`
+	AccessTokenTTL  time.Duration `validate:"required"`
+	RefreshTokenTTL time.Duration `validate:"required"`
+
+	// how long revocation lookups are cached in-process
+	RevocationCacheTTL time.Duration `validate:"required"`
+
+	EmailVerificationTTL time.Duration `validate:"required"`
+	PasswordResetTTL     time.Duration `validate:"required"`
+	// when set, routes wrapped in RequireVerifiedEmail reject unverified accounts
+	RequireVerifiedEmail bool
 }
 
 type EmailConfig struct {
 	ResendAPIKey string `validate:"required"`
 	FromEmail    string `validate:"required,email"`
+	VerifyURL    string `validate:"required,url"`
+	ResetURL     string `validate:"required,url"`
+}
+
+type ProductsConfig struct {
+	// how long deleted products stay restorable before they're purged
+	TrashRetention time.Duration `validate:"required"`
+}
+
+type StorageConfig struct {
+	// where uploaded files go: local or s3
+	Backend  string `validate:"required,oneof=local s3"`
+	LocalDir string `validate:"required_if=Backend local"`
+
+	// any S3-compatible service; point S3Endpoint at e.g.
+	// http://localhost:9000 to develop against a local MinIO
+	S3Endpoint  string `validate:"required_if=Backend s3"`
+	S3Region    string `validate:"required_if=Backend s3"`
+	S3Bucket    string `validate:"required_if=Backend s3"`
+	S3AccessKey string `validate:"required_if=Backend s3"`
+	S3SecretKey string `validate:"required_if=Backend s3"`
+	// bucket in the path instead of the hostname, as most stand-ins need
+	S3PathStyle bool
+}
+
+type ImagesConfig struct {
+	MaxBytes int `validate:"required,min=1"`
+	// caps decoded size, so a small file can't expand into gigabytes
+	MaxPixels     int `validate:"required,min=1"`
+	ThumbnailSize int `validate:"required,min=16"`
+	MaxPerProduct int `validate:"required,min=1"`
+
+	// image links are signed with URLSecret and expire after URLTTL
+	BaseURL   string        `validate:"required,url"`
+	URLSecret string        `validate:"required,min=32"`
+	URLTTL    time.Duration `validate:"required"`
+}
+
+type CartConfig struct {
+	// anonymous carts are found through a cookie signed with
+	// CookieSecret, and deleted once untouched for AnonymousTTL
+	CookieSecret string        `validate:"required,min=32"`
+	AnonymousTTL time.Duration `validate:"required"`
+	// requests per minute per IP
+	RateLimit int `validate:"required,min=1"`
+}
+
+type CatalogConfig struct {
+	// how long public catalog responses are cached, both in-process and
+	// by browsers and CDNs through Cache-Control
+	CacheTTL time.Duration `validate:"required"`
+	// requests per minute per IP
+	RateLimit int `validate:"required,min=1"`
 }
 
 func Load() (*Config, error) {
@@ -55,11 +130,13 @@ func Load() (*Config, error) {
 			Env: getEnv("APP_ENV", "development"),
 		},
 		Server: ServerConfig{
-			Port:          getEnvAsInt("PORT", 8080),
-			ReadTimeout:   getEnvAsDuration("READ_TIMEOUT", 10*time.Second),
-			WriteTimeout:  getEnvAsDuration("WRITE_TIMEOUT", 10*time.Second),
-			IdleTimeout:   getEnvAsDuration("IDLE_TIMEOUT", 60*time.Second),
-			AllowedOrigin: getEnv("ALLOWED_ORIGIN", "http://localhost:5173"),
+			Port:              getEnvAsInt("PORT", 8080),
+			ReadTimeout:       getEnvAsDuration("READ_TIMEOUT", 10*time.Second),
+			WriteTimeout:      getEnvAsDuration("WRITE_TIMEOUT", 10*time.Second),
+			IdleTimeout:       getEnvAsDuration("IDLE_TIMEOUT", 60*time.Second),
+			AllowedOrigin:     getEnv("ALLOWED_ORIGIN", "http://localhost:5173"),
+			RequireIfMatch:    getEnvAsBool("REQUIRE_IF_MATCH", false),
+			IdempotencyKeyTTL: getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
 		},
 		Database: DatabaseConfig{
 			URL:             getEnv("DATABASE_URL", ""),
@@ -69,11 +146,54 @@ func Load() (*Config, error) {
 			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 30*time.Minute),
 		},
 		Auth: AuthConfig{
-			JWTSecret: getEnv("JWT_SECRET", ""),
+			JWTSecret:       getEnv("JWT_SECRET", ""),
+			AccessTokenTTL:  getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL: getEnvAsDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+
+			RevocationCacheTTL: getEnvAsDuration("REVOCATION_CACHE_TTL", 30*time.Second),
+
+			EmailVerificationTTL: getEnvAsDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			PasswordResetTTL:     getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+			RequireVerifiedEmail: getEnvAsBool("REQUIRE_VERIFIED_EMAIL", false),
 		},
 		Email: EmailConfig{
 			ResendAPIKey: getEnv("RESEND_API_KEY", ""),
 			FromEmail:    getEnv("FROM_EMAIL", "onboarding@resend.dev"),
+			VerifyURL:    getEnv("EMAIL_VERIFY_URL", "http://localhost:8080/api/v1/auth/verify"),
+			ResetURL:     getEnv("PASSWORD_RESET_URL", "http://localhost:5173/reset-password"),
+		},
+		Products: ProductsConfig{
+			TrashRetention: getEnvAsDuration("PRODUCT_TRASH_RETENTION", 30*24*time.Hour),
+		},
+		Storage: StorageConfig{
+			Backend:     getEnv("STORAGE_BACKEND", "local"),
+			LocalDir:    getEnv("STORAGE_LOCAL_DIR", "uploads"),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvAsBool("S3_PATH_STYLE", false),
+		},
+		Images: ImagesConfig{
+			MaxBytes:      getEnvAsInt("IMAGE_MAX_BYTES", 10<<20),
+			MaxPixels:     getEnvAsInt("IMAGE_MAX_PIXELS", 25_000_000),
+			ThumbnailSize: getEnvAsInt("IMAGE_THUMBNAIL_SIZE", 320),
+			MaxPerProduct: getEnvAsInt("IMAGE_MAX_PER_PRODUCT", 20),
+			BaseURL:       getEnv("IMAGE_BASE_URL", "http://localhost:8080/api/v1/images"),
+			// falls back to a key derived from the JWT secret so existing
+			// setups keep working
+			URLSecret: getEnv("IMAGE_URL_SECRET", derivedSecret("image-url")),
+			URLTTL:    getEnvAsDuration("IMAGE_URL_TTL", time.Hour),
+		},
+		Cart: CartConfig{
+			CookieSecret: getEnv("CART_COOKIE_SECRET", derivedSecret("cart-cookie")),
+			AnonymousTTL: getEnvAsDuration("CART_ANONYMOUS_TTL", 30*24*time.Hour),
+			RateLimit:    getEnvAsInt("CART_RATE_LIMIT", 60),
+		},
+		Catalog: CatalogConfig{
+			CacheTTL:  getEnvAsDuration("CATALOG_CACHE_TTL", time.Minute),
+			RateLimit: getEnvAsInt("CATALOG_RATE_LIMIT", 120),
 		},
 	}
 
@@ -92,6 +212,20 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// derivedSecret is HMAC-SHA256(JWT_SECRET, purpose), hex-encoded: a
+// key of its own for signing something other than tokens, so a
+// signature made for one purpose is never valid for another. It's
+// empty without a JWT secret, which fails validation.
+func derivedSecret(purpose string) string {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func getEnvAsInt(key string, fallback int) int {
 	valStr := os.Getenv(key)
 	if valStr == "" {
@@ -115,3 +249,15 @@ func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
 	}
 	return val
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return fallback
+	}
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		panic(fmt.Sprintf("invalid boolean for %s", key))
+	}
+	return val
+}
@@ -6,27 +6,298 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
 	"github.com/google/uuid"
 )
 
+type AdminAuditLog struct {
+	ID           uuid.UUID       `json:"id"`
+	AdminID      uuid.UUID       `json:"admin_id"`
+	TargetUserID uuid.UUID       `json:"target_user_id"`
+	Action       string          `json:"action"`
+	Details      json.RawMessage `json:"details"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type BlobDeletion struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Cart struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.NullUUID `json:"user_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type CartItem struct {
+	CartID    uuid.UUID     `json:"cart_id"`
+	ProductID uuid.UUID     `json:"product_id"`
+	Quantity  int32         `json:"quantity"`
+	UnitPrice money.Decimal `json:"unit_price"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type CatalogProduct struct {
+	ID            uuid.UUID         `json:"id"`
+	Name          string            `json:"name"`
+	Description   sql.NullString    `json:"description"`
+	Price         money.Decimal     `json:"price"`
+	Currency      string            `json:"currency"`
+	InStock       bool              `json:"in_stock"`
+	RatingCount   int32             `json:"rating_count"`
+	RatingAverage money.NullDecimal `json:"rating_average"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Version       int32             `json:"-"`
+}
+
+type Category struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	ParentID  uuid.NullUUID `json:"parent_id"`
+	Name      string        `json:"name"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type EmailVerificationToken struct {
+	Jti       uuid.UUID    `json:"jti"`
+	UserID    uuid.UUID    `json:"user_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	UserID          uuid.UUID       `json:"user_id"`
+	Key             string          `json:"key"`
+	Fingerprint     string          `json:"fingerprint"`
+	StatusCode      sql.NullInt32   `json:"status_code"`
+	ResponseHeaders json.RawMessage `json:"response_headers"`
+	ResponseBody    []byte          `json:"response_body"`
+	CreatedAt       time.Time       `json:"created_at"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+type Order struct {
+	ID          uuid.UUID     `json:"id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	Status      string        `json:"status"`
+	Total       money.Decimal `json:"total"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	PaidAt      sql.NullTime  `json:"paid_at"`
+	ShippedAt   sql.NullTime  `json:"shipped_at"`
+	DeliveredAt sql.NullTime  `json:"delivered_at"`
+	CancelledAt sql.NullTime  `json:"cancelled_at"`
+	Currency    string        `json:"currency"`
+	Subtotal    money.Decimal `json:"subtotal"`
+	Discount    money.Decimal `json:"discount"`
+}
+
+type OrderItem struct {
+	ID          uuid.UUID     `json:"id"`
+	OrderID     uuid.UUID     `json:"order_id"`
+	ProductID   uuid.NullUUID `json:"product_id"`
+	ProductName string        `json:"product_name"`
+	UnitPrice   money.Decimal `json:"unit_price"`
+	Quantity    int32         `json:"quantity"`
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type PriceHistory struct {
+	ID                uuid.UUID     `json:"id"`
+	ProductID         uuid.UUID     `json:"product_id"`
+	UserID            uuid.NullUUID `json:"user_id"`
+	Price             money.Decimal `json:"price"`
+	Currency          string        `json:"currency"`
+	Reason            string        `json:"reason"`
+	ScheduledChangeID uuid.NullUUID `json:"scheduled_change_id"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
 type Product struct {
-	ID          uuid.UUID      `json:"id"`
-	UserID      uuid.UUID      `json:"user_id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	Price       string         `json:"price"`
-	Stock       int32          `json:"stock"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID            uuid.UUID         `json:"id"`
+	UserID        uuid.UUID         `json:"user_id"`
+	Name          string            `json:"name"`
+	Description   sql.NullString    `json:"description"`
+	Price         money.Decimal     `json:"price"`
+	Stock         int32             `json:"stock"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	SearchVector  string            `json:"-"`
+	Version       int32             `json:"version"`
+	DeletedAt     sql.NullTime      `json:"deleted_at"`
+	Currency      string            `json:"currency"`
+	RatingCount   int32             `json:"rating_count"`
+	RatingSum     int32             `json:"-"`
+	RatingAverage money.NullDecimal `json:"rating_average"`
+	IsPublished   bool              `json:"is_published"`
 }
 
-type User struct {
+type ProductCategory struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+}
+
+type ProductImage struct {
+	ID           uuid.UUID `json:"id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ProductImport struct {
+	ID           uuid.UUID       `json:"id"`
+	UserID       uuid.UUID       `json:"user_id"`
+	Mode         string          `json:"mode"`
+	Status       string          `json:"status"`
+	TotalRows    int32           `json:"total_rows"`
+	InsertedRows int32           `json:"inserted_rows"`
+	FailedRows   int32           `json:"failed_rows"`
+	Errors       json.RawMessage `json:"errors"`
+	CreatedAt    time.Time       `json:"created_at"`
+	FinishedAt   sql.NullTime    `json:"finished_at"`
+}
+
+type ProductTag struct {
+	ProductID uuid.UUID `json:"product_id"`
+	TagID     uuid.UUID `json:"tag_id"`
+}
+
+type ProductVariant struct {
+	ID        uuid.UUID         `json:"id"`
+	ProductID uuid.UUID         `json:"product_id"`
+	UserID    uuid.UUID         `json:"user_id"`
+	Sku       string            `json:"sku"`
+	Options   json.RawMessage   `json:"options"`
+	Price     money.NullDecimal `json:"price"`
+	Stock     sql.NullInt32     `json:"stock"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type Promotion struct {
+	ID             uuid.UUID         `json:"id"`
+	Name           string            `json:"name"`
+	Code           sql.NullString    `json:"code"`
+	Kind           string            `json:"kind"`
+	Value          money.Decimal     `json:"value"`
+	Currency       sql.NullString    `json:"currency"`
+	MinOrder       money.NullDecimal `json:"min_order"`
+	MaxUses        sql.NullInt32     `json:"max_uses"`
+	MaxUsesPerUser sql.NullInt32     `json:"max_uses_per_user"`
+	StartsAt       sql.NullTime      `json:"starts_at"`
+	EndsAt         sql.NullTime      `json:"ends_at"`
+	Active         bool              `json:"active"`
+	CreatedBy      uuid.NullUUID     `json:"created_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type PromotionProduct struct {
+	PromotionID uuid.UUID `json:"promotion_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+}
+
+type PromotionRedemption struct {
+	ID          uuid.UUID     `json:"id"`
+	PromotionID uuid.UUID     `json:"promotion_id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	OrderID     uuid.UUID     `json:"order_id"`
+	Amount      money.Decimal `json:"amount"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type RefreshToken struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	FamilyID   uuid.UUID     `json:"family_id"`
+	TokenHash  string        `json:"token_hash"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	RevokedAt  sql.NullTime  `json:"revoked_at"`
+	ReplacedBy uuid.NullUUID `json:"replaced_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type Review struct {
+	ID          uuid.UUID     `json:"id"`
+	ProductID   uuid.UUID     `json:"product_id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	Rating      int16         `json:"rating"`
+	Body        string        `json:"body"`
+	Status      string        `json:"status"`
+	ModeratedBy uuid.NullUUID `json:"moderated_by"`
+	ModeratedAt sql.NullTime  `json:"moderated_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+type RevokedToken struct {
+	Jti       uuid.UUID `json:"jti"`
+	UserID    uuid.UUID `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledPriceChange struct {
+	ID          uuid.UUID     `json:"id"`
+	ProductID   uuid.UUID     `json:"product_id"`
+	UserID      uuid.NullUUID `json:"user_id"`
+	Price       money.Decimal `json:"price"`
+	Currency    string        `json:"currency"`
+	EffectiveAt time.Time     `json:"effective_at"`
+	Status      string        `json:"status"`
+	ProcessedAt sql.NullTime  `json:"processed_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type StockMovement struct {
+	ID         uuid.UUID      `json:"id"`
+	ProductID  uuid.UUID      `json:"product_id"`
+	UserID     uuid.NullUUID  `json:"user_id"`
+	Reason     string         `json:"reason"`
+	Delta      int32          `json:"delta"`
+	StockAfter int32          `json:"stock_after"`
+	Note       sql.NullString `json:"note"`
+	CreatedAt  time.Time      `json:"created_at"`
+	VariantID  uuid.NullUUID  `json:"variant_id"`
+}
+
+type Tag struct {
 	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Role      string    `json:"role"`
+}
+
+type User struct {
+	ID              uuid.UUID    `json:"id"`
+	Email           string       `json:"email"`
+	Password        string       `json:"password"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+	Role            string       `json:"role"`
+	TokenVersion    int32        `json:"token_version"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+	DisabledAt      sql.NullTime `json:"disabled_at"`
 }
@@ -8,31 +8,151 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
 	"github.com/google/uuid"
 )
 
+const adjustProductRating = `-- name: AdjustProductRating :exec
+UPDATE products
+SET rating_count = rating_count + $1,
+    rating_sum   = rating_sum + $2,
+    version      = version + 1
+WHERE id = $3
+`
+
+type AdjustProductRatingParams struct {
+	CountDelta int32     `json:"count_delta"`
+	SumDelta   int32     `json:"sum_delta"`
+	ID         uuid.UUID `json:"id"`
+}
+
+// Moves the running rating totals as an approved review is added,
+// re-rated or withdrawn. A rating isn't an edit, so updated_at stays,
+// but version moves so cached copies go stale. Products in the trash
+// change too.
+func (q *Queries) AdjustProductRating(ctx context.Context, arg AdjustProductRatingParams) error {
+	_, err := q.db.ExecContext(ctx, adjustProductRating, arg.CountDelta, arg.SumDelta, arg.ID)
+	return err
+}
+
+const adjustProductStock = `-- name: AdjustProductStock :one
+UPDATE products
+SET stock      = stock + $1,
+    version    = version + 1,
+    updated_at = NOW()
+WHERE id = $2
+  AND ($3::uuid IS NULL OR user_id = $3)
+  AND deleted_at IS NULL
+  AND stock + $1 >= 0
+RETURNING id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published
+`
+
+type AdjustProductStockParams struct {
+	Delta  int32         `json:"delta"`
+	ID     uuid.UUID     `json:"id"`
+	UserID uuid.NullUUID `json:"user_id"`
+}
+
+// Applies a stock delta unless it would take stock below zero, in
+// which case no row comes back. The caller records the movement in
+// the same transaction.
+func (q *Queries) AdjustProductStock(ctx context.Context, arg AdjustProductStockParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, adjustProductStock, arg.Delta, arg.ID, arg.UserID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
+	)
+	return i, err
+}
+
+const countSearchProducts = `-- name: CountSearchProducts :one
+SELECT COUNT(*) FROM products
+WHERE search_vector @@ to_tsquery('english', $1)
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND deleted_at IS NULL
+`
+
+type CountSearchProductsParams struct {
+	Query  string        `json:"query"`
+	UserID uuid.NullUUID `json:"user_id"`
+}
+
+func (q *Queries) CountSearchProducts(ctx context.Context, arg CountSearchProductsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchProducts, arg.Query, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTrashedProducts = `-- name: CountTrashedProducts :one
+SELECT COUNT(*) FROM products
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountTrashedProducts(ctx context.Context, userID uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTrashedProducts, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProduct = `-- name: CreateProduct :one
-INSERT INTO products (user_id, name, description, price, stock)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, name, description, price, stock, created_at, updated_at
+WITH product AS (
+    INSERT INTO products (user_id, name, description, price, currency, stock, is_published)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
+    RETURNING id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published
+), opening AS (
+    INSERT INTO stock_movements (product_id, user_id, reason, delta, stock_after, note)
+    SELECT id, user_id, 'adjustment', stock, stock, 'initial stock'
+    FROM product
+    WHERE stock > 0
+), first_price AS (
+    INSERT INTO price_history (product_id, user_id, price, currency, reason)
+    SELECT id, user_id, price, currency, 'initial'
+    FROM product
+)
+SELECT id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published FROM product
 `
 
 type CreateProductParams struct {
 	UserID      uuid.UUID      `json:"user_id"`
 	Name        string         `json:"name"`
 	Description sql.NullString `json:"description"`
-	Price       string         `json:"price"`
+	Price       money.Decimal  `json:"price"`
+	Currency    string         `json:"currency"`
 	Stock       int32          `json:"stock"`
+	IsPublished bool           `json:"is_published"`
 }
 
+// Any starting stock is recorded in the ledger, and the price in the
+// price history, in the same statement.
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
 	row := q.db.QueryRowContext(ctx, createProduct,
 		arg.UserID,
 		arg.Name,
 		arg.Description,
 		arg.Price,
+		arg.Currency,
 		arg.Stock,
+		arg.IsPublished,
 	)
 	var i Product
 	err := row.Scan(
@@ -44,34 +164,55 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
 	)
 	return i, err
 }
 
-const deleteProduct = `-- name: DeleteProduct :exec
-DELETE FROM products
-WHERE id = $1 AND user_id = $2
+const deleteProduct = `-- name: DeleteProduct :execrows
+UPDATE products
+SET
+    deleted_at = NOW(),
+    version    = version + 1
+WHERE id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND ($3::integer IS NULL OR version = $3)
+  AND deleted_at IS NULL
 `
 
 type DeleteProductParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID              uuid.UUID     `json:"id"`
+	UserID          uuid.NullUUID `json:"user_id"`
+	ExpectedVersion sql.NullInt32 `json:"expected_version"`
 }
 
-func (q *Queries) DeleteProduct(ctx context.Context, arg DeleteProductParams) error {
-	_, err := q.db.ExecContext(ctx, deleteProduct, arg.ID, arg.UserID)
-	return err
+// Moves the product to the trash. PurgeProduct deletes it for real.
+func (q *Queries) DeleteProduct(ctx context.Context, arg DeleteProductParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProduct, arg.ID, arg.UserID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT id, user_id, name, description, price, stock, created_at, updated_at FROM products
-WHERE id = $1 AND user_id = $2
+SELECT id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published FROM products
+WHERE id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND deleted_at IS NULL
 LIMIT 1
 `
 
 type GetProductByIDParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID     `json:"id"`
+	UserID uuid.NullUUID `json:"user_id"`
 }
 
 func (q *Queries) GetProductByID(ctx context.Context, arg GetProductByIDParams) (Product, error) {
@@ -86,18 +227,72 @@ func (q *Queries) GetProductByID(ctx context.Context, arg GetProductByIDParams)
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
 	)
 	return i, err
 }
 
-const listProductsByUser = `-- name: ListProductsByUser :many
-SELECT id, user_id, name, description, price, stock, created_at, updated_at FROM products
-WHERE user_id = $1
-ORDER BY created_at DESC
+const getProductForUpdate = `-- name: GetProductForUpdate :one
+SELECT id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published FROM products
+WHERE id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND deleted_at IS NULL
+FOR NO KEY UPDATE
 `
 
-func (q *Queries) ListProductsByUser(ctx context.Context, userID uuid.UUID) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, listProductsByUser, userID)
+type GetProductForUpdateParams struct {
+	ID     uuid.UUID     `json:"id"`
+	UserID uuid.NullUUID `json:"user_id"`
+}
+
+// like GetProductByID, but holds the row until the transaction ends
+func (q *Queries) GetProductForUpdate(ctx context.Context, arg GetProductForUpdateParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductForUpdate, arg.ID, arg.UserID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
+	)
+	return i, err
+}
+
+const listTrashedProducts = `-- name: ListTrashedProducts :many
+SELECT id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published FROM products
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $2 OFFSET $3
+`
+
+type ListTrashedProductsParams struct {
+	UserID uuid.NullUUID `json:"user_id"`
+	Limit  int32         `json:"limit"`
+	Offset int32         `json:"offset"`
+}
+
+func (q *Queries) ListTrashedProducts(ctx context.Context, arg ListTrashedProductsParams) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashedProducts, arg.UserID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +309,14 @@ func (q *Queries) ListProductsByUser(ctx context.Context, userID uuid.UUID) ([]P
 			&i.Stock,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Version,
+			&i.DeletedAt,
+			&i.Currency,
+			&i.RatingCount,
+			&i.RatingSum,
+			&i.RatingAverage,
+			&i.IsPublished,
 		); err != nil {
 			return nil, err
 		}
@@ -128,35 +331,296 @@ func (q *Queries) ListProductsByUser(ctx context.Context, userID uuid.UUID) ([]P
 	return items, nil
 }
 
-const updateProduct = `-- name: UpdateProduct :one
+const purgeProduct = `-- name: PurgeProduct :execrows
+DELETE FROM products
+WHERE id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND deleted_at IS NOT NULL
+`
+
+type PurgeProductParams struct {
+	ID     uuid.UUID     `json:"id"`
+	UserID uuid.NullUUID `json:"user_id"`
+}
+
+// Only trashed products can be purged
+func (q *Queries) PurgeProduct(ctx context.Context, arg PurgeProductParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeProduct, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeTrashedProducts = `-- name: PurgeTrashedProducts :execrows
+DELETE FROM products
+WHERE deleted_at < $1
+`
+
+// Deletes everything that has been in the trash since before cutoff
+func (q *Queries) PurgeTrashedProducts(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeTrashedProducts, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const releaseProductStock = `-- name: ReleaseProductStock :one
+UPDATE products
+SET stock      = stock + $1,
+    version    = version + 1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published
+`
+
+type ReleaseProductStockParams struct {
+	Quantity int32     `json:"quantity"`
+	ID       uuid.UUID `json:"id"`
+}
+
+// Puts stock back, e.g. from a cancelled order. Unlike
+// AdjustProductStock it also reaches products in the trash.
+func (q *Queries) ReleaseProductStock(ctx context.Context, arg ReleaseProductStockParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, releaseProductStock, arg.Quantity, arg.ID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
+	)
+	return i, err
+}
+
+const restoreProduct = `-- name: RestoreProduct :one
 UPDATE products
 SET
-    name        = COALESCE($1, name),
-    description = COALESCE($2, description),
-    price       = COALESCE($3, price),
-    stock       = COALESCE($4, stock),
-    updated_at  = NOW()
-WHERE id = $5 AND user_id = $6
-RETURNING id, user_id, name, description, price, stock, created_at, updated_at
+    deleted_at = NULL,
+    version    = version + 1
+WHERE id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND deleted_at IS NOT NULL
+RETURNING id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published
 `
 
-type UpdateProductParams struct {
+type RestoreProductParams struct {
+	ID     uuid.UUID     `json:"id"`
+	UserID uuid.NullUUID `json:"user_id"`
+}
+
+func (q *Queries) RestoreProduct(ctx context.Context, arg RestoreProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, restoreProduct, arg.ID, arg.UserID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
+	)
+	return i, err
+}
+
+const searchProducts = `-- name: SearchProducts :many
+SELECT
+    id, user_id, name, description, price, stock, created_at, updated_at, version, currency,
+    ts_rank(search_vector, to_tsquery('english', $1))::real AS rank,
+    -- the text is HTML-escaped before highlighting, so the only markup
+    -- in a headline is the <mark> tags
+    ts_headline(
+        'english',
+        replace(replace(replace(concat_ws(' ', name, description), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        to_tsquery('english', $1),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5'
+    ) AS headline
+FROM products
+WHERE search_vector @@ to_tsquery('english', $1)
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND deleted_at IS NULL
+ORDER BY rank DESC, id
+LIMIT $3 OFFSET $4
+`
+
+type SearchProductsParams struct {
+	Query  string        `json:"query"`
+	UserID uuid.NullUUID `json:"user_id"`
+	Limit  int32         `json:"limit"`
+	Offset int32         `json:"offset"`
+}
+
+type SearchProductsRow struct {
+	ID          uuid.UUID      `json:"id"`
+	UserID      uuid.UUID      `json:"user_id"`
 	Name        string         `json:"name"`
 	Description sql.NullString `json:"description"`
-	Price       string         `json:"price"`
+	Price       money.Decimal  `json:"price"`
 	Stock       int32          `json:"stock"`
-	ID          uuid.UUID      `json:"id"`
-	UserID      uuid.UUID      `json:"user_id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Version     int32          `json:"version"`
+	Currency    string         `json:"currency"`
+	Rank        float32        `json:"rank"`
+	Headline    string         `json:"headline"`
+}
+
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchProducts,
+		arg.Query,
+		arg.UserID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchProductsRow
+	for rows.Next() {
+		var i SearchProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Currency,
+			&i.Rank,
+			&i.Headline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setScheduledProductPrice = `-- name: SetScheduledProductPrice :one
+UPDATE products
+SET price      = $1,
+    version    = version + 1,
+    updated_at = NOW()
+WHERE id = $2
+  AND currency = $3
+RETURNING id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published
+`
+
+type SetScheduledProductPriceParams struct {
+	Price    money.Decimal `json:"price"`
+	ID       uuid.UUID     `json:"id"`
+	Currency string        `json:"currency"`
+}
+
+// Applies a scheduled price, as long as the product is still in the
+// currency it was scheduled in; otherwise no row comes back. Products
+// in the trash change too, so they're current if restored.
+func (q *Queries) SetScheduledProductPrice(ctx context.Context, arg SetScheduledProductPriceParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, setScheduledProductPrice, arg.Price, arg.ID, arg.Currency)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
+	)
+	return i, err
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+SET
+    name         = COALESCE($1, name),
+    description  = CASE
+                       WHEN $2::boolean THEN NULL
+                       ELSE COALESCE($3, description)
+                   END,
+    price        = COALESCE($4, price),
+    currency     = COALESCE($5, currency),
+    stock        = COALESCE($6, stock),
+    is_published = COALESCE($7, is_published),
+    version      = version + 1,
+    updated_at   = NOW()
+WHERE id = $8
+  AND ($9::uuid IS NULL OR user_id = $9)
+  AND ($10::integer IS NULL OR version = $10)
+  AND deleted_at IS NULL
+RETURNING id, user_id, name, description, price, stock, created_at, updated_at, search_vector, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published
+`
+
+type UpdateProductParams struct {
+	Name             sql.NullString    `json:"name"`
+	ClearDescription bool              `json:"clear_description"`
+	Description      sql.NullString    `json:"description"`
+	Price            money.NullDecimal `json:"price"`
+	Currency         sql.NullString    `json:"currency"`
+	Stock            sql.NullInt32     `json:"stock"`
+	IsPublished      sql.NullBool      `json:"is_published"`
+	ID               uuid.UUID         `json:"id"`
+	UserID           uuid.NullUUID     `json:"user_id"`
+	ExpectedVersion  sql.NullInt32     `json:"expected_version"`
 }
 
+// NULL arguments leave their column alone. description is nullable
+// itself, so clearing it goes through clear_description. A non-NULL
+// expected_version makes the update conditional on the row's version.
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
 	row := q.db.QueryRowContext(ctx, updateProduct,
 		arg.Name,
+		arg.ClearDescription,
 		arg.Description,
 		arg.Price,
+		arg.Currency,
 		arg.Stock,
+		arg.IsPublished,
 		arg.ID,
 		arg.UserID,
+		arg.ExpectedVersion,
 	)
 	var i Product
 	err := row.Scan(
@@ -168,6 +632,14 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Version,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.RatingCount,
+		&i.RatingSum,
+		&i.RatingAverage,
+		&i.IsPublished,
 	)
 	return i, err
 }
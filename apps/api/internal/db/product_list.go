@@ -0,0 +1,342 @@
+package db
+
+// Hand-written, not generated: product listing needs a dynamic WHERE and
+// ORDER BY (optional filters, whitelisted sort columns, keyset cursor),
+// which sqlc can't express. Everything here is parameterised; the only
+// identifiers spliced into SQL come from productSortColumns.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store is everything the services need from the database:
+// the generated queries plus the hand-written ones in this package
+type Store interface {
+	Querier
+	ListProductsPage(ctx context.Context, arg ListProductsPageParams) ([]Product, error)
+	ListVariantsForProducts(ctx context.Context, productIDs []uuid.UUID) ([]ProductVariant, error)
+}
+
+var _ Store = (*Queries)(nil)
+
+// productSortColumns maps API sort keys to columns and the SQL type their
+// cursor value is cast to. Anything not in here can't be sorted on.
+var productSortColumns = map[string]struct {
+	column string
+	cast   string
+}{
+	"created_at": {"created_at", "timestamptz"},
+	"updated_at": {"updated_at", "timestamptz"},
+	"name":       {"name", "text"},
+	"price":      {"price", "numeric"},
+	"stock":      {"stock", "integer"},
+}
+
+// ValidProductSort reports whether key is a whitelisted sort field
+func ValidProductSort(key string) bool {
+	_, ok := productSortColumns[key]
+	return ok
+}
+
+// ProductFilter narrows a product listing. Zero values mean "no filter";
+// a NULL UserID means every owner and is only used for admin scopes.
+type ProductFilter struct {
+	UserID     uuid.NullUUID
+	Currency   string
+	MinPrice   string
+	MaxPrice   string
+	MinStock   *int32
+	MaxStock   *int32
+	NamePrefix string
+	// CategoryID matches products in the category or any of its
+	// descendants
+	CategoryID uuid.NullUUID
+	// Tags are lower-cased tag names. A product matches if it has any
+	// of them, or every one of them when AllTags is set.
+	Tags    []string
+	AllTags bool
+}
+
+// ProductCursor is the position after which the next page starts:
+// the sort column's value (as text) and the row ID as a tie-breaker
+type ProductCursor struct {
+	Value string
+	ID    uuid.UUID
+}
+
+type ListProductsPageParams struct {
+	Filter ProductFilter
+	Sort   string
+	Desc   bool
+	After  *ProductCursor
+	Limit  int32
+}
+
+const productColumns = "id, user_id, name, description, price, stock, created_at, updated_at, version, deleted_at, currency, rating_count, rating_sum, rating_average, is_published"
+
+// ListProductsPage returns one keyset page ordered by (sort column, id)
+func (q *Queries) ListProductsPage(ctx context.Context, arg ListProductsPageParams) ([]Product, error) {
+	query, args, err := buildProductQuery(arg.Filter, arg.Sort, arg.Desc, arg.After)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, arg.Limit)
+	query += fmt.Sprintf("\nLIMIT $%d", len(args))
+
+	return q.scanProducts(ctx, query, args...)
+}
+
+// scanProducts runs a query selecting productColumns
+func (q *Queries) scanProducts(ctx context.Context, query string, args ...any) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.Currency,
+			&i.RatingCount,
+			&i.RatingSum,
+			&i.RatingAverage,
+			&i.IsPublished,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariantsForProducts = `SELECT id, product_id, user_id, sku, options, price, stock, created_at, updated_at
+FROM product_variants
+WHERE product_id = ANY($1::uuid[])
+ORDER BY product_id, created_at, id`
+
+// ListVariantsForProducts fetches the variants of a page of products in
+// one round trip. It lives here because sqlc's database/sql output
+// passes arrays through lib/pq, which pgx doesn't need.
+func (q *Queries) ListVariantsForProducts(ctx context.Context, productIDs []uuid.UUID) ([]ProductVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listVariantsForProducts, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProductVariant
+	for rows.Next() {
+		var i ProductVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.UserID,
+			&i.Sku,
+			&i.Options,
+			&i.Price,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ExportProductsParams struct {
+	Filter ProductFilter
+	Sort   string
+	Desc   bool
+	// StatementTimeout caps each statement and IdleTimeout how long the
+	// transaction may sit between them while fn works, so a stalled
+	// export can't hold its connection forever. Zero keeps the server's
+	// setting.
+	StatementTimeout time.Duration
+	IdleTimeout      time.Duration
+}
+
+// exportFetchSize is how many rows each FETCH pulls from the cursor
+const exportFetchSize = 500
+
+// ExportProducts walks every product matching the filter through a
+// server-side cursor, exportFetchSize rows at a time, calling fn for
+// each one. Only one batch is ever held in memory. Cursors live inside
+// a transaction, so q must be bound to one.
+func (q *Queries) ExportProducts(ctx context.Context, arg ExportProductsParams, fn func(Product) error) error {
+	query, args, err := buildProductQuery(arg.Filter, arg.Sort, arg.Desc, nil)
+	if err != nil {
+		return err
+	}
+
+	// SET takes no parameters; the values are plain integers
+	if arg.StatementTimeout > 0 {
+		if _, err := q.db.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", arg.StatementTimeout.Milliseconds())); err != nil {
+			return err
+		}
+	}
+	if arg.IdleTimeout > 0 {
+		if _, err := q.db.ExecContext(ctx, fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout = %d", arg.IdleTimeout.Milliseconds())); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.db.ExecContext(ctx, "DECLARE product_export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM product_export", exportFetchSize)
+	for {
+		items, err := q.scanProducts(ctx, fetch)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if len(items) < exportFetchSize {
+			break
+		}
+	}
+
+	_, err = q.db.ExecContext(ctx, "CLOSE product_export")
+	return err
+}
+
+func buildProductQuery(f ProductFilter, sort string, desc bool, after *ProductCursor) (string, []any, error) {
+	col, ok := productSortColumns[sort]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown sort field %q", sort)
+	}
+
+	var (
+		// trashed products only show up in the trash listing
+		where = []string{"deleted_at IS NULL"}
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.UserID.Valid {
+		where = append(where, "user_id = "+arg(f.UserID.UUID))
+	}
+	if f.Currency != "" {
+		where = append(where, "currency = "+arg(f.Currency))
+	}
+	if f.MinPrice != "" {
+		where = append(where, "price >= "+arg(f.MinPrice)+"::numeric")
+	}
+	if f.MaxPrice != "" {
+		where = append(where, "price <= "+arg(f.MaxPrice)+"::numeric")
+	}
+	if f.MinStock != nil {
+		where = append(where, "stock >= "+arg(*f.MinStock))
+	}
+	if f.MaxStock != nil {
+		where = append(where, "stock <= "+arg(*f.MaxStock))
+	}
+	if f.NamePrefix != "" {
+		where = append(where, "lower(name) LIKE "+arg(likePrefix(f.NamePrefix)))
+	}
+
+	if f.CategoryID.Valid {
+		where = append(where, fmt.Sprintf(categorySubtreeFilter, arg(f.CategoryID.UUID)))
+	}
+	if len(f.Tags) > 0 {
+		tags := fmt.Sprintf(tagFilter, arg(f.Tags))
+		if f.AllTags {
+			tags += " GROUP BY pt.product_id HAVING count(*) = " + arg(len(f.Tags))
+		}
+		where = append(where, "id IN ("+tags+")")
+	}
+
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if after != nil {
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			col.column, cmp, arg(after.Value), col.cast, arg(after.ID)))
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + productColumns + " FROM products")
+	b.WriteString("\nWHERE " + strings.Join(where, "\n  AND "))
+	fmt.Fprintf(&b, "\nORDER BY %s %s, id %s", col.column, dir, dir)
+
+	return b.String(), args, nil
+}
+
+// categorySubtreeFilter matches products linked to the category or
+// anything below it. UNION (not UNION ALL) keeps the walk finite even
+// if the tree were ever to contain a cycle.
+const categorySubtreeFilter = `id IN (
+    WITH RECURSIVE subtree AS (
+        SELECT id FROM categories WHERE id = %s
+        UNION
+        SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+    )
+    SELECT pc.product_id FROM product_categories pc JOIN subtree ON subtree.id = pc.category_id
+  )`
+
+// tagFilter selects products carrying any of the named tags. Tag names
+// are unique per user, so with the names deduplicated, a product has
+// all of them exactly when it matches len(names) times.
+const tagFilter = "SELECT pt.product_id FROM product_tags pt JOIN tags t ON t.id = pt.tag_id" +
+	" WHERE lower(t.name) = ANY(%s::text[])"
+
+// likePrefix escapes LIKE wildcards so the prefix matches literally
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return strings.ToLower(r.Replace(prefix)) + "%"
+}
+
+// ProductCursorValue renders the sort column's value for p the way
+// the cursor comparison expects it
+func ProductCursorValue(p Product, sort string) string {
+	switch sort {
+	case "updated_at":
+		return p.UpdatedAt.Format(time.RFC3339Nano)
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price.String()
+	case "stock":
+		return fmt.Sprint(p.Stock)
+	default:
+		return p.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
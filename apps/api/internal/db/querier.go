@@ -6,19 +6,163 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AddCartItem(ctx context.Context, arg AddCartItemParams) (CartItem, error)
+	AddProductCategory(ctx context.Context, arg AddProductCategoryParams) (int64, error)
+	AddProductTag(ctx context.Context, arg AddProductTagParams) error
+	AddPromotionProduct(ctx context.Context, arg AddPromotionProductParams) (int64, error)
+	AdjustProductRating(ctx context.Context, arg AdjustProductRatingParams) error
+	AdjustProductStock(ctx context.Context, arg AdjustProductStockParams) (Product, error)
+	AdjustVariantStock(ctx context.Context, arg AdjustVariantStockParams) (ProductVariant, error)
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error)
+	ClearProductCategories(ctx context.Context, productID uuid.UUID) error
+	ClearProductTags(ctx context.Context, productID uuid.UUID) error
+	ClearPromotionProducts(ctx context.Context, promotionID uuid.UUID) error
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	ConsumeEmailVerificationToken(ctx context.Context, jti uuid.UUID) (uuid.UUID, error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	CountCatalogProducts(ctx context.Context) (int64, error)
+	CountOrders(ctx context.Context, arg CountOrdersParams) (int64, error)
+	CountPriceHistory(ctx context.Context, productID uuid.UUID) (int64, error)
+	CountProductImages(ctx context.Context, productID uuid.UUID) (int64, error)
+	CountProductReviews(ctx context.Context, arg CountProductReviewsParams) (int64, error)
+	CountProductVariants(ctx context.Context, productID uuid.UUID) (int64, error)
+	CountPromotionRedemptions(ctx context.Context, promotionID uuid.UUID) (int64, error)
+	CountPromotions(ctx context.Context) (int64, error)
+	CountReviewsByStatus(ctx context.Context, status sql.NullString) (int64, error)
+	CountSearchProducts(ctx context.Context, arg CountSearchProductsParams) (int64, error)
+	CountStockMovements(ctx context.Context, productID uuid.UUID) (int64, error)
+	CountTrashedProducts(ctx context.Context, userID uuid.NullUUID) (int64, error)
+	CountUserPromotionRedemptions(ctx context.Context, arg CountUserPromotionRedemptionsParams) (int64, error)
+	CountUsers(ctx context.Context) (int64, error)
+	CountVariantStockMovements(ctx context.Context, variantID uuid.NullUUID) (int64, error)
+	CreateAdminAuditLog(ctx context.Context, arg CreateAdminAuditLogParams) error
+	CreateAnonymousCart(ctx context.Context) (Cart, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) error
+	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error
+	CreatePriceHistoryEntry(ctx context.Context, arg CreatePriceHistoryEntryParams) (PriceHistory, error)
 	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	CreateProductImage(ctx context.Context, arg CreateProductImageParams) (ProductImage, error)
+	CreateProductImport(ctx context.Context, arg CreateProductImportParams) (ProductImport, error)
+	CreateProductVariant(ctx context.Context, arg CreateProductVariantParams) (ProductVariant, error)
+	CreatePromotion(ctx context.Context, arg CreatePromotionParams) (Promotion, error)
+	CreatePromotionRedemption(ctx context.Context, arg CreatePromotionRedemptionParams) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error)
+	CreateScheduledPriceChange(ctx context.Context, arg CreateScheduledPriceChangeParams) (ScheduledPriceChange, error)
+	CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) (StockMovement, error)
+	CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteProduct(ctx context.Context, arg DeleteProductParams) error
+	DeleteBlobDeletion(ctx context.Context, key string) error
+	DeleteCart(ctx context.Context, id uuid.UUID) error
+	DeleteCategory(ctx context.Context, arg DeleteCategoryParams) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	DeleteOrderRedemptions(ctx context.Context, orderID uuid.UUID) error
+	DeleteProduct(ctx context.Context, arg DeleteProductParams) (int64, error)
+	DeleteProductImage(ctx context.Context, arg DeleteProductImageParams) (int64, error)
+	DeleteProductVariant(ctx context.Context, arg DeleteProductVariantParams) (int64, error)
+	DeletePromotion(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteReview(ctx context.Context, id uuid.UUID) error
+	DeleteScheduledPriceChange(ctx context.Context, arg DeleteScheduledPriceChangeParams) (int64, error)
+	DeleteStaleAnonymousCarts(ctx context.Context, updatedAt time.Time) (int64, error)
+	DeleteTag(ctx context.Context, arg DeleteTagParams) (int64, error)
+	DisableUser(ctx context.Context, id uuid.UUID) (User, error)
+	EnableUser(ctx context.Context, id uuid.UUID) (User, error)
+	FailStaleProductImports(ctx context.Context, createdAt time.Time) (int64, error)
+	FinishProductImport(ctx context.Context, arg FinishProductImportParams) error
+	GetAnonymousCart(ctx context.Context, id uuid.UUID) (Cart, error)
+	GetCatalogProduct(ctx context.Context, id uuid.UUID) (CatalogProduct, error)
+	GetCategory(ctx context.Context, arg GetCategoryParams) (Category, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetOrder(ctx context.Context, arg GetOrderParams) (Order, error)
+	GetOrderForUpdate(ctx context.Context, arg GetOrderForUpdateParams) (Order, error)
 	GetProductByID(ctx context.Context, arg GetProductByIDParams) (Product, error)
+	GetProductForUpdate(ctx context.Context, arg GetProductForUpdateParams) (Product, error)
+	GetProductImage(ctx context.Context, arg GetProductImageParams) (ProductImage, error)
+	GetProductImport(ctx context.Context, arg GetProductImportParams) (ProductImport, error)
+	GetProductVariant(ctx context.Context, arg GetProductVariantParams) (ProductVariant, error)
+	GetProductVariantForUpdate(ctx context.Context, arg GetProductVariantForUpdateParams) (ProductVariant, error)
+	GetPromotion(ctx context.Context, id uuid.UUID) (Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (Promotion, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetReview(ctx context.Context, id uuid.UUID) (Review, error)
+	GetReviewForUpdate(ctx context.Context, id uuid.UUID) (Review, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
-	ListProductsByUser(ctx context.Context, userID uuid.UUID) ([]Product, error)
+	GetUserCart(ctx context.Context, userID uuid.NullUUID) (Cart, error)
+	GetUserSessionState(ctx context.Context, id uuid.UUID) (GetUserSessionStateRow, error)
+	IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) (int32, error)
+	InvalidateUserEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error
+	IsCategoryInSubtree(ctx context.Context, arg IsCategoryInSubtreeParams) (bool, error)
+	IsTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error)
+	ListAdminAuditLogsByTarget(ctx context.Context, arg ListAdminAuditLogsByTargetParams) ([]AdminAuditLog, error)
+	ListAutomaticPromotions(ctx context.Context, now time.Time) ([]Promotion, error)
+	ListBlobDeletions(ctx context.Context, limit int32) ([]string, error)
+	ListCartItems(ctx context.Context, cartID uuid.UUID) ([]ListCartItemsRow, error)
+	ListCatalogProducts(ctx context.Context, arg ListCatalogProductsParams) ([]CatalogProduct, error)
+	ListCategories(ctx context.Context, userID uuid.UUID) ([]Category, error)
+	ListOrderItems(ctx context.Context, orderID uuid.UUID) ([]OrderItem, error)
+	ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error)
+	ListPriceHistory(ctx context.Context, arg ListPriceHistoryParams) ([]PriceHistory, error)
+	ListProductCategories(ctx context.Context, productID uuid.UUID) ([]Category, error)
+	ListProductImages(ctx context.Context, productID uuid.UUID) ([]ProductImage, error)
+	ListProductReviews(ctx context.Context, arg ListProductReviewsParams) ([]Review, error)
+	ListProductTags(ctx context.Context, productID uuid.UUID) ([]Tag, error)
+	ListProductVariants(ctx context.Context, productID uuid.UUID) ([]ProductVariant, error)
+	ListPromotionProducts(ctx context.Context, promotionID uuid.UUID) ([]uuid.UUID, error)
+	ListPromotions(ctx context.Context, arg ListPromotionsParams) ([]Promotion, error)
+	ListReviewsByStatus(ctx context.Context, arg ListReviewsByStatusParams) ([]Review, error)
+	ListScheduledPriceChanges(ctx context.Context, productID uuid.UUID) ([]ScheduledPriceChange, error)
+	ListStockMovements(ctx context.Context, arg ListStockMovementsParams) ([]StockMovement, error)
+	ListTags(ctx context.Context, userID uuid.UUID) ([]Tag, error)
+	ListTrashedProducts(ctx context.Context, arg ListTrashedProductsParams) ([]Product, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListVariantStockMovements(ctx context.Context, arg ListVariantStockMovementsParams) ([]StockMovement, error)
+	LockPromotion(ctx context.Context, id uuid.UUID) (Promotion, error)
+	LockUserCategories(ctx context.Context, userID uuid.UUID) error
+	MarkScheduledPriceChange(ctx context.Context, arg MarkScheduledPriceChangeParams) error
+	MarkUserEmailVerified(ctx context.Context, id uuid.UUID) error
+	MergeCartItems(ctx context.Context, arg MergeCartItemsParams) error
+	NextDueScheduledPriceChange(ctx context.Context) (ScheduledPriceChange, error)
+	PurgeProduct(ctx context.Context, arg PurgeProductParams) (int64, error)
+	PurgeTrashedProducts(ctx context.Context, cutoff time.Time) (int64, error)
+	ReleaseProductStock(ctx context.Context, arg ReleaseProductStockParams) (Product, error)
+	RemoveCartItem(ctx context.Context, arg RemoveCartItemParams) (int64, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) (Tag, error)
+	RestoreProduct(ctx context.Context, arg RestoreProductParams) (Product, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (int64, error)
+	SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error)
+	SetCartItemQuantity(ctx context.Context, arg SetCartItemQuantityParams) (CartItem, error)
+	SetOrderTotal(ctx context.Context, arg SetOrderTotalParams) (Order, error)
+	SetReviewStatus(ctx context.Context, arg SetReviewStatusParams) (Review, error)
+	SetScheduledProductPrice(ctx context.Context, arg SetScheduledProductPriceParams) (Product, error)
+	TouchCart(ctx context.Context, id uuid.UUID) error
+	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error)
+	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error)
 	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
+	UpdateProductImportProgress(ctx context.Context, arg UpdateProductImportProgressParams) error
+	UpdateProductVariant(ctx context.Context, arg UpdateProductVariantParams) (ProductVariant, error)
+	UpdatePromotion(ctx context.Context, arg UpdatePromotionParams) (Promotion, error)
+	UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpsertTag(ctx context.Context, arg UpsertTagParams) (Tag, error)
+	UpsertUserCart(ctx context.Context, userID uuid.NullUUID) (Cart, error)
 }
 
 var _ Querier = (*Queries)(nil)
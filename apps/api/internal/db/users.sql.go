@@ -7,14 +7,26 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password, role)
 VALUES ($1, $2, $3)
-RETURNING id, email, password, created_at, updated_at, role
+RETURNING id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at
 `
 
 type CreateUserParams struct {
@@ -33,12 +45,67 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET
+    disabled_at = COALESCE(disabled_at, NOW()),
+    updated_at  = NOW()
+WHERE id = $1
+RETURNING id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const enableUser = `-- name: EnableUser :one
+UPDATE users
+SET
+    disabled_at = NULL,
+    updated_at  = NOW()
+WHERE id = $1
+RETURNING id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password, created_at, updated_at, role FROM users
+SELECT id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at FROM users
 WHERE email = $1
 LIMIT 1
 `
@@ -53,12 +120,15 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password, created_at, updated_at, role FROM users
+SELECT id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at FROM users
 WHERE id = $1
 LIMIT 1
 `
@@ -73,6 +143,149 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getUserSessionState = `-- name: GetUserSessionState :one
+SELECT token_version, disabled_at FROM users
+WHERE id = $1
+LIMIT 1
+`
+
+type GetUserSessionStateRow struct {
+	TokenVersion int32        `json:"token_version"`
+	DisabledAt   sql.NullTime `json:"disabled_at"`
+}
+
+func (q *Queries) GetUserSessionState(ctx context.Context, id uuid.UUID) (GetUserSessionStateRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserSessionState, id)
+	var i GetUserSessionStateRow
+	err := row.Scan(&i.TokenVersion, &i.DisabledAt)
+	return i, err
+}
+
+const incrementUserTokenVersion = `-- name: IncrementUserTokenVersion :one
+UPDATE users
+SET
+    token_version = token_version + 1,
+    updated_at    = NOW()
+WHERE id = $1
+RETURNING token_version
+`
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, incrementUserTokenVersion, id)
+	var token_version int32
+	err := row.Scan(&token_version)
+	return token_version, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at FROM users
+ORDER BY created_at DESC, id
+LIMIT $1 OFFSET $2
+`
+
+type ListUsersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Password,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Role,
+			&i.TokenVersion,
+			&i.EmailVerifiedAt,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :exec
+UPDATE users
+SET
+    email_verified_at = NOW(),
+    updated_at        = NOW()
+WHERE id = $1 AND email_verified_at IS NULL
+`
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markUserEmailVerified, id)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET
+    password   = $1,
+    updated_at = NOW()
+WHERE id = $2
+`
+
+type UpdateUserPasswordParams struct {
+	Password string    `json:"password"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.Password, arg.ID)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET
+    role       = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, email, password, created_at, updated_at, role, token_version, email_verified_at, disabled_at
+`
+
+type UpdateUserRoleParams struct {
+	Role string    `json:"role"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.Role, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
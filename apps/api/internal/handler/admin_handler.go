@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+type changeRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// @Summary      List users
+// @Description  Paginated list of every user account
+// @Tags         admin
+// @Produce      json
+// @Param        page      query int false "Page number (1-based)"
+// @Param        page_size query int false "Page size (max 100)"
+// @Success      200 {array}  UserResponse
+// @Failure      403 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users [get]
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.adminService.ListUsers(r.Context(), page)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch users")
+		return
+	}
+
+	users := make([]UserResponse, 0, len(result.Users))
+	for _, u := range result.Users {
+		users = append(users, toUserResponse(u))
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, users, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Get user
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} UserResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.adminService.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err, "could not fetch user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// @Summary      Change user role
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path string            true "User ID"
+// @Param        request body changeRoleRequest true "New role"
+// @Success      200 {object} UserResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users/{id}/role [put]
+func (h *AdminHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req changeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	user, err := h.adminService.ChangeRole(r.Context(), adminID, chi.URLParam(r, "id"), req.Role)
+	if err != nil {
+		writeAdminError(w, err, "could not change role")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// @Summary      Disable user
+// @Description  Block the account from logging in and revoke all its sessions
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} UserResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users/{id}/disable [post]
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+
+	user, err := h.adminService.DisableUser(r.Context(), adminID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err, "could not disable user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// @Summary      Re-enable user
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} UserResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users/{id}/enable [post]
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+
+	user, err := h.adminService.EnableUser(r.Context(), adminID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err, "could not enable user")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// @Summary      Force logout
+// @Description  Revoke every session the user has, on all devices
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users/{id}/logout [post]
+func (h *AdminHandler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+
+	if err := h.adminService.ForceLogout(r.Context(), adminID, chi.URLParam(r, "id")); err != nil {
+		writeAdminError(w, err, "could not log user out")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "user logged out everywhere"})
+}
+
+// @Summary      User audit log
+// @Description  Admin actions taken against a user, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        id        path  string true  "User ID"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success      200 {array}  AuditLogResponse
+// @Security     CookieAuth
+// @Router       /api/v1/admin/users/{id}/audit [get]
+func (h *AdminHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	entries, err := h.adminService.AuditLog(r.Context(), chi.URLParam(r, "id"), page)
+	if err != nil {
+		writeAdminError(w, err, "could not fetch audit log")
+		return
+	}
+
+	if entries == nil {
+		entries = []db.AdminAuditLog{}
+	}
+	response.JSON(w, http.StatusOK, entries)
+}
+
+func writeAdminError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.Error(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, service.ErrInvalidRole):
+		response.Error(w, http.StatusBadRequest, "invalid role")
+	case errors.Is(err, service.ErrCannotModifySelf):
+		response.Error(w, http.StatusBadRequest, "cannot perform this action on your own account")
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// toUserResponse strips the password hash and flattens nullable columns
+func toUserResponse(u db.User) UserResponse {
+	res := UserResponse{
+		ID:            u.ID.String(),
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerifiedAt.Valid,
+		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     u.UpdatedAt.Format(time.RFC3339),
+	}
+	if u.DisabledAt.Valid {
+		res.DisabledAt = u.DisabledAt.Time.Format(time.RFC3339)
+	}
+	return res
+}
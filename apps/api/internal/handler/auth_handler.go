@@ -50,7 +50,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authService.Register(r.Context(), req.Email, req.Password)
+	tokens, err := h.authService.Register(r.Context(), req.Email, req.Password)
 	if err != nil {
 		if errors.Is(err, service.ErrEmailTaken) {
 			response.Error(w, http.StatusConflict, "email already in use")
@@ -60,12 +60,12 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	setAuthCookie(w, token)
+	setAuthCookies(w, tokens)
 	response.JSON(w, http.StatusCreated, map[string]string{"message": "registered successfully"})
 }
 
 // @Summary      Login
-// @Description  Login with email and password
+// @Description  Login with email and password. An anonymous cart in the cart cookie is merged into the user's cart.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -85,45 +85,293 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authService.Login(r.Context(), req.Email, req.Password)
+	var cartToken string
+	if cookie, err := r.Cookie(cartCookieName); err == nil {
+		cartToken = cookie.Value
+	}
+
+	tokens, cartMerged, err := h.authService.Login(r.Context(), req.Email, req.Password, cartToken)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidCreds) {
 			response.Error(w, http.StatusUnauthorized, "invalid email or password")
 			return
 		}
+		if errors.Is(err, service.ErrAccountDisabled) {
+			response.Error(w, http.StatusForbidden, "account disabled")
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, "something went wrong")
 		return
 	}
 
-	setAuthCookie(w, token)
+	setAuthCookies(w, tokens)
+	// a cart that failed to merge stays behind its cookie for next time
+	if cartToken != "" && cartMerged {
+		clearCartCookie(w)
+	}
 	response.JSON(w, http.StatusOK, map[string]string{"message": "logged in successfully"})
 }
 
+// @Summary      Refresh session
+// @Description  Exchange the refresh token cookie for a new token pair
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /api/v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing refresh token")
+		return
+	}
+
+	tokens, err := h.authService.Refresh(r.Context(), cookie.Value)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) ||
+			errors.Is(err, service.ErrRefreshTokenReused) ||
+			errors.Is(err, service.ErrAccountDisabled) {
+			clearAuthCookies(w)
+			response.Error(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	setAuthCookies(w, tokens)
+	response.JSON(w, http.StatusOK, map[string]string{"message": "session refreshed"})
+}
+
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    "",
-		HttpOnly: true,
-		Path:     "/",
-		MaxAge:   -1, // delete the cookie immediately
-	})
+	var accessToken, refreshToken string
+	if cookie, err := r.Cookie(middleware.AccessCookieName); err == nil {
+		accessToken = cookie.Value
+	}
+	if cookie, err := r.Cookie(refreshCookieName); err == nil {
+		refreshToken = cookie.Value
+	}
+
+	if err := h.authService.Logout(r.Context(), accessToken, refreshToken); err != nil {
+		response.Error(w, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	clearAuthCookies(w)
 	response.JSON(w, http.StatusOK, map[string]string{"message": "logged out"})
 }
 
-// setAuthCookie sets the JWT as an httpOnly cookie
-func setAuthCookie(w http.ResponseWriter, token string) {
+// @Summary      Log out everywhere
+// @Description  Revoke every session the current user has, on all devices
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	if err := h.authService.LogoutEverywhere(r.Context(), userID); err != nil {
+		response.Error(w, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	clearAuthCookies(w)
+	response.JSON(w, http.StatusOK, map[string]string{"message": "logged out everywhere"})
+}
+
+const (
+	refreshCookieName = "refresh_token"
+
+	// the refresh token is only ever needed by the auth endpoints
+	refreshCookiePath = "/api/v1/auth"
+)
+
+// setAuthCookies sets the access JWT and the refresh token as httpOnly cookies
+func setAuthCookies(w http.ResponseWriter, tokens service.TokenPair) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    token,
+		Name:     middleware.AccessCookieName,
+		Value:    tokens.AccessToken,
 		HttpOnly: true, // not accessible via JavaScript
 		Path:     "/",
-		MaxAge:   86400, // 24 hours in seconds
+		MaxAge:   int(tokens.AccessTokenTTL.Seconds()),
 		SameSite: http.SameSiteLaxMode,
 		// Secure: true  // uncomment in production (requires HTTPS)
 	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    tokens.RefreshToken,
+		HttpOnly: true,
+		Path:     refreshCookiePath,
+		MaxAge:   int(tokens.RefreshTokenTTL.Seconds()),
+		SameSite: http.SameSiteLaxMode,
+		// Secure: true  // uncomment in production (requires HTTPS)
+	})
+}
+
+func clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.AccessCookieName,
+		Value:    "",
+		HttpOnly: true,
+		Path:     "/",
+		MaxAge:   -1, // delete the cookie immediately
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		HttpOnly: true,
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+	})
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// @Summary      Verify email
+// @Description  Confirm an email address with the token from the verification email. Accepts the token as a query parameter (GET) or JSON body (POST).
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token   query string             false "Verification token (GET)"
+// @Param        request body  verifyEmailRequest false "Verification token (POST)"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Router       /api/v1/auth/verify [get]
+// @Router       /api/v1/auth/verify [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if r.Method == http.MethodGet {
+		req.Token = r.URL.Query().Get("token")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	if err := h.authService.VerifyEmail(r.Context(), req.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerification) {
+			response.Error(w, http.StatusBadRequest, "invalid or expired verification token")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	// if this browser holds a session, reissue it so the access
+	// token reflects the verified address right away
+	if cookie, err := r.Cookie(refreshCookieName); err == nil {
+		if tokens, err := h.authService.Refresh(r.Context(), cookie.Value); err == nil {
+			setAuthCookies(w, tokens)
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "email verified"})
+}
+
+// @Summary      Resend verification email
+// @Description  Send a fresh verification link to the current user
+// @Tags         auth
+// @Produce      json
+// @Success      202 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/auth/verify/resend [post]
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	if err := h.authService.ResendVerification(r.Context(), userID); err != nil {
+		if errors.Is(err, service.ErrAlreadyVerified) {
+			response.Error(w, http.StatusConflict, "email already verified")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, map[string]string{"message": "verification email sent"})
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"    validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// @Summary      Forgot password
+// @Description  Email a password reset link. Always responds the same way, whether or not the account exists.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body forgotPasswordRequest true "Account email"
+// @Success      202 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /api/v1/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	h.authService.ForgotPassword(r.Context(), req.Email)
+	response.JSON(w, http.StatusAccepted, map[string]string{
+		"message": "if that email is registered, a reset link is on its way",
+	})
+}
+
+// @Summary      Reset password
+// @Description  Set a new password with the emailed reset token. Logs out every existing session.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body resetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Router       /api/v1/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			response.Error(w, http.StatusBadRequest, "invalid or expired reset token")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "something went wrong")
+		return
+	}
+
+	clearAuthCookies(w)
+	response.JSON(w, http.StatusOK, map[string]string{"message": "password reset, please log in again"})
 }
 
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(middleware.UserIDKey).(string)
-	response.JSON(w, http.StatusOK, map[string]string{"user_id": userID})
+	role, _ := r.Context().Value(middleware.RoleKey).(string)
+	response.JSON(w, http.StatusOK, map[string]string{"user_id": userID, "role": role})
 }
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// cartCookieName holds the signed ID of an anonymous shopper's cart
+const cartCookieName = "cart"
+
+// CartHandler serves logged-in and anonymous shoppers alike. Mount it
+// behind OptionalAuth: with a user in the context it works on their
+// cart, otherwise on the one the cart cookie points at.
+type CartHandler struct {
+	cartService *service.CartService
+}
+
+func NewCartHandler(cartService *service.CartService) *CartHandler {
+	return &CartHandler{cartService: cartService}
+}
+
+type addCartItemRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int32  `json:"quantity"   validate:"required,min=1,max=10000"`
+}
+
+type setCartItemRequest struct {
+	Quantity int32 `json:"quantity" validate:"required,min=1,max=10000"`
+}
+
+// @Summary      Get cart
+// @Description  The shopper's cart, checked against current prices and stock. Each item lists its issues: unavailable, insufficient_stock, price_changed or currency_mismatch. Works without logging in.
+// @Tags         cart
+// @Produce      json
+// @Success      200 {object} CartResponse
+// @Failure      429 {object} map[string]string
+// @Router       /api/v1/cart [get]
+func (h *CartHandler) Get(w http.ResponseWriter, r *http.Request) {
+	cart, err := h.cartService.Get(r.Context(), cartOwner(r))
+	if err != nil {
+		writeCartError(w, err, "could not fetch cart")
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+// @Summary      Add to cart
+// @Description  Add a product to the cart, on top of any quantity already there. The total is capped at 10000.
+// @Tags         cart
+// @Accept       json
+// @Produce      json
+// @Param        request body addCartItemRequest true "Item"
+// @Success      200 {object} CartResponse
+// @Failure      400 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /api/v1/cart/items [post]
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var req addCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	cart, err := h.cartService.AddItem(r.Context(), cartOwner(r), req.ProductID, req.Quantity)
+	if err != nil {
+		writeCartError(w, err, "could not add to cart")
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+// @Summary      Change cart quantity
+// @Tags         cart
+// @Accept       json
+// @Produce      json
+// @Param        product_id path string             true "Product ID"
+// @Param        request    body setCartItemRequest true "New quantity"
+// @Success      200 {object} CartResponse
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /api/v1/cart/items/{product_id} [put]
+func (h *CartHandler) SetQuantity(w http.ResponseWriter, r *http.Request) {
+	var req setCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	cart, err := h.cartService.SetQuantity(r.Context(), cartOwner(r), chi.URLParam(r, "product_id"), req.Quantity)
+	if err != nil {
+		writeCartError(w, err, "could not update cart")
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+// @Summary      Remove from cart
+// @Tags         cart
+// @Produce      json
+// @Param        product_id path string true "Product ID"
+// @Success      200 {object} CartResponse
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /api/v1/cart/items/{product_id} [delete]
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	cart, err := h.cartService.RemoveItem(r.Context(), cartOwner(r), chi.URLParam(r, "product_id"))
+	if err != nil {
+		writeCartError(w, err, "could not update cart")
+		return
+	}
+
+	writeCart(w, http.StatusOK, cart)
+}
+
+// cartOwner is the logged-in user, or failing that the cart cookie
+func cartOwner(r *http.Request) service.CartOwner {
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(string); ok {
+		return service.CartOwner{UserID: userID}
+	}
+	if cookie, err := r.Cookie(cartCookieName); err == nil {
+		return service.CartOwner{Token: cookie.Value}
+	}
+	return service.CartOwner{}
+}
+
+// writeCart renews the cookie of an anonymous cart with every response
+func writeCart(w http.ResponseWriter, status int, cart service.Cart) {
+	if cart.Token != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cartCookieName,
+			Value:    cart.Token,
+			HttpOnly: true,
+			Path:     "/",
+			MaxAge:   int(time.Until(cart.TokenExpires).Seconds()),
+			SameSite: http.SameSiteLaxMode,
+			// Secure: true  // uncomment in production (requires HTTPS)
+		})
+	}
+	response.JSON(w, status, cart)
+}
+
+// clearCartCookie drops the anonymous cart once it's been merged
+func clearCartCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cartCookieName,
+		Value:    "",
+		HttpOnly: true,
+		Path:     "/",
+		MaxAge:   -1,
+	})
+}
+
+func writeCartError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrProductUnavailable):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "product_id", Message: "product is not available"},
+		})
+	case errors.Is(err, money.ErrCurrencyMismatch):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "product_id", Message: "is priced in a different currency from the rest of the cart"},
+		})
+	case errors.Is(err, service.ErrCartItemNotFound):
+		response.Error(w, http.StatusNotFound, "product is not in the cart")
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
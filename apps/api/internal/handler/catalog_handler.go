@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// CatalogHandler serves published products to anyone, signed in or
+// not. Everything it returns is public, so responses are cacheable by
+// browsers and shared caches for maxAge.
+type CatalogHandler struct {
+	catalogService *service.CatalogService
+	maxAge         time.Duration
+}
+
+func NewCatalogHandler(catalogService *service.CatalogService, maxAge time.Duration) *CatalogHandler {
+	return &CatalogHandler{
+		catalogService: catalogService,
+		maxAge:         maxAge,
+	}
+}
+
+// @Summary      List catalog
+// @Description  Published products, newest first. No sign-in needed. Responds with an ETag; send it back as If-None-Match to get a 304 when nothing changed.
+// @Tags         catalog
+// @Produce      json
+// @Param        page          query  int    false "Page number (1-based)"
+// @Param        page_size     query  int    false "Page size (max 100)"
+// @Param        If-None-Match header string false "ETag from a previous GET"
+// @Success      200 {array}  CatalogProductResponse
+// @Success      304
+// @Failure      400 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /api/v1/catalog [get]
+func (h *CatalogHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.catalogService.List(r.Context(), page)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch catalog")
+		return
+	}
+
+	products := result.Products
+	if products == nil {
+		products = []db.CatalogProduct{}
+	}
+
+	if h.notModified(w, r, catalogPageETag(products, page, result.Total)) {
+		return
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, products, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Get catalog product
+// @Description  A published product. No sign-in needed. Unpublished and deleted products are 404.
+// @Tags         catalog
+// @Produce      json
+// @Param        id            path   string true  "Product ID"
+// @Param        If-None-Match header string false "ETag from a previous GET"
+// @Success      200 {object} CatalogProductResponse
+// @Success      304
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /api/v1/catalog/{id} [get]
+func (h *CatalogHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	product, err := h.catalogService.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			response.Error(w, http.StatusNotFound, "product not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "could not fetch product")
+		return
+	}
+
+	if h.notModified(w, r, catalogETag(product)) {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, product)
+}
+
+// notModified sets the caching headers and answers 304 when the
+// client's copy is still current
+func (h *CatalogHandler) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
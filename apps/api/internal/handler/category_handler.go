@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+type categoryRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// ParentID is empty or null for a top-level category
+	ParentID string `json:"parent_id"`
+}
+
+// @Summary      List categories
+// @Description  Every category the user has, as a flat list. Each one names its parent, so clients can build the tree.
+// @Tags         categories
+// @Produce      json
+// @Success      200 {array}  CategoryResponse
+// @Security     CookieAuth
+// @Router       /api/v1/categories [get]
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	categories, err := h.categoryService.List(r.Context(), userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch categories")
+		return
+	}
+
+	if categories == nil {
+		categories = []db.Category{}
+	}
+	response.JSON(w, http.StatusOK, categories)
+}
+
+// @Summary      Create category
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        request body categoryRequest true "Category"
+// @Success      201 {object} CategoryResponse
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/categories [post]
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	req, ok := decodeCategoryRequest(w, r)
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.Create(r.Context(), userID, service.CategoryInput{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		writeCategoryError(w, err, "could not create category")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, category)
+}
+
+// @Summary      Get category
+// @Tags         categories
+// @Produce      json
+// @Param        id path string true "Category ID"
+// @Success      200 {object} CategoryResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/categories/{id} [get]
+func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	category, err := h.categoryService.Get(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeCategoryError(w, err, "could not fetch category")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, category)
+}
+
+// @Summary      Update category
+// @Description  Rename a category or move it under another parent. It can't be moved under itself or one of its own subcategories.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id      path string          true "Category ID"
+// @Param        request body categoryRequest true "Category"
+// @Success      200 {object} CategoryResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/categories/{id} [put]
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	req, ok := decodeCategoryRequest(w, r)
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.Update(r.Context(), userID, chi.URLParam(r, "id"), service.CategoryInput{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		writeCategoryError(w, err, "could not update category")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, category)
+}
+
+// @Summary      Delete category
+// @Description  Products in the category are unlinked, not deleted. Categories with subcategories can't be deleted.
+// @Tags         categories
+// @Param        id path string true "Category ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/categories/{id} [delete]
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	if err := h.categoryService.Delete(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeCategoryError(w, err, "could not delete category")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func decodeCategoryRequest(w http.ResponseWriter, r *http.Request) (categoryRequest, bool) {
+	var req categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return req, false
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return req, false
+	}
+
+	return req, true
+}
+
+func writeCategoryError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		response.Error(w, http.StatusNotFound, "category not found")
+	case errors.Is(err, service.ErrInvalidCategory):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "parent_id", Message: "unknown category"},
+		})
+	case errors.Is(err, service.ErrCategoryCycle):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "parent_id", Message: "cannot move a category under itself or its subcategories"},
+		})
+	case errors.Is(err, service.ErrCategoryExists):
+		response.Error(w, http.StatusConflict, "a category with this name already exists here")
+	case errors.Is(err, service.ErrCategoryHasChildren):
+		response.Error(w, http.StatusConflict, "category still has subcategories")
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
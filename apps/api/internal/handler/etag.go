@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+)
+
+// productETag is a strong validator for a product: version changes on
+// every write, and the ID keeps tags from matching across products
+func productETag(p db.Product) string {
+	return fmt.Sprintf(`"%s-%d"`, p.ID, p.Version)
+}
+
+// catalogETag is productETag for the public view of a product
+func catalogETag(p db.CatalogProduct) string {
+	return fmt.Sprintf(`"%s-%d"`, p.ID, p.Version)
+}
+
+// catalogPageETag is a weak validator for a page of the catalog: it
+// changes when any product on it does, or the page's place in the list
+func catalogPageETag(products []db.CatalogProduct, page service.Page, total int64) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d/%d/%d", page.Number, page.Size, total)
+	for _, p := range products {
+		fmt.Fprintf(h, ";%s-%d", p.ID, p.Version)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header
+// matches etag. If-Match compares strongly, so weak tags never match;
+// If-None-Match compares weakly and ignores the W/ prefix.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type OrderHandler struct {
+	orderService *service.OrderService
+	allUsers     bool
+}
+
+func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
+	return &OrderHandler{orderService: orderService}
+}
+
+// NewAdminOrderHandler sees every user's orders. Mount it only behind
+// RequirePermission(rbac.PermManageOrders).
+func NewAdminOrderHandler(orderService *service.OrderService) *OrderHandler {
+	return &OrderHandler{orderService: orderService, allUsers: true}
+}
+
+func (h *OrderHandler) scope(r *http.Request) service.Scope {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	if h.allUsers {
+		return service.AdminScope(userID)
+	}
+	return service.OwnerScope(userID)
+}
+
+type orderItemRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int32  `json:"quantity"   validate:"required,min=1,max=10000"`
+}
+
+type createOrderRequest struct {
+	Items []orderItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+	// CouponCode is a promotion code to apply, matched ignoring case
+	CouponCode string `json:"coupon_code" validate:"omitempty,max=64"`
+}
+
+type orderStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=paid shipped delivered cancelled"`
+}
+
+// @Summary      Place order
+// @Description  Reserve stock for every line and snapshot the current prices, less any promotions that apply and the coupon in coupon_code. Fails with 409 if any product is short of stock, and 422 if one is missing, in the trash, or priced in a different currency from the first line, or if the coupon is unknown or can't be used on this order.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        request body createOrderRequest true "Order lines"
+// @Success      201 {object} OrderResponse
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/orders [post]
+func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	items := make([]service.OrderItemInput, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, service.OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	order, err := h.orderService.Create(r.Context(), h.scope(r), items, req.CouponCode)
+	if err != nil {
+		writeOrderError(w, err, "could not place order")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, order)
+}
+
+// @Summary      List orders
+// @Description  Your orders, newest first. Admins see everyone's.
+// @Tags         orders
+// @Produce      json
+// @Param        status    query string false "Only orders in this status"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success      200 {array}  OrderResponse
+// @Failure      400 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/orders [get]
+func (h *OrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !service.ValidOrderStatus(status) {
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "status", Message: "must be one of pending, paid, shipped, delivered, cancelled"},
+		})
+		return
+	}
+
+	result, err := h.orderService.List(r.Context(), h.scope(r), status, page)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch orders")
+		return
+	}
+
+	orders := result.Orders
+	if orders == nil {
+		orders = []db.Order{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, orders, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Get order
+// @Tags         orders
+// @Produce      json
+// @Param        id path string true "Order ID"
+// @Success      200 {object} OrderResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/orders/{id} [get]
+func (h *OrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	order, err := h.orderService.Get(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeOrderError(w, err, "could not fetch order")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, order)
+}
+
+// @Summary      Cancel order
+// @Description  Cancel a pending or paid order and put its stock back
+// @Tags         orders
+// @Produce      json
+// @Param        id path string true "Order ID"
+// @Success      200 {object} OrderResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/orders/{id}/cancel [post]
+func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	order, err := h.orderService.Cancel(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeOrderError(w, err, "could not cancel order")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, order)
+}
+
+// @Summary      Change order status
+// @Description  Move an order along pending → paid → shipped → delivered, or cancel it before it ships. Cancelling puts its stock back.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path string             true "Order ID"
+// @Param        request body orderStatusRequest true "New status"
+// @Success      200 {object} OrderResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/orders/{id}/status [post]
+func (h *OrderHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	var req orderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	order, err := h.orderService.Transition(r.Context(), h.scope(r), chi.URLParam(r, "id"), req.Status)
+	if err != nil {
+		writeOrderError(w, err, "could not update order")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, order)
+}
+
+func writeOrderError(w http.ResponseWriter, err error, fallback string) {
+	var itemErr *service.OrderItemError
+	var couponErr *service.CouponError
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		response.Error(w, http.StatusNotFound, "order not found")
+	case errors.Is(err, service.ErrOrderTransition):
+		response.Error(w, http.StatusConflict, "order cannot move to that status")
+	case errors.Is(err, service.ErrEmptyOrder):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "items", Message: "must contain at least one item"},
+		})
+	case errors.As(err, &itemErr) && errors.Is(err, service.ErrInsufficientStock):
+		response.Error(w, http.StatusConflict, fmt.Sprintf("not enough stock for items[%d]", itemErr.Index))
+	case errors.As(err, &itemErr) && errors.Is(err, money.ErrCurrencyMismatch):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: fmt.Sprintf("items[%d].product_id", itemErr.Index), Message: "is priced in a different currency from the rest of the order"},
+		})
+	case errors.As(err, &itemErr):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: fmt.Sprintf("items[%d].product_id", itemErr.Index), Message: "product is not available"},
+		})
+	case errors.Is(err, service.ErrCouponNotFound):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "coupon_code", Message: "unknown coupon code"},
+		})
+	case errors.As(err, &couponErr):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "coupon_code", Message: couponMessages[couponErr.Reason]},
+		})
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+)
+
+type pageMeta struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+// parsePage reads ?page= and ?page_size=, falling back to defaults
+// and capping the size so one request can't pull the whole table.
+// Pages that would start past service.MaxOffset are rejected.
+func parsePage(r *http.Request) (service.Page, bool) {
+	page := service.Page{Number: 1, Size: service.DefaultPageSize}
+
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return service.Page{}, false
+		}
+		page.Number = n
+	}
+
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return service.Page{}, false
+		}
+		page.Size = min(n, service.MaxPageSize)
+	}
+
+	if page.Number-1 > service.MaxOffset/page.Size {
+		return service.Page{}, false
+	}
+
+	return page, true
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/xlsx"
+)
+
+// productExporter writes products in one export format
+type productExporter interface {
+	Write(p db.Product) error
+	Close() error
+}
+
+var exportFormats = map[string]struct {
+	contentType string
+	open        func(w io.Writer) (productExporter, error)
+}{
+	"csv":    {"text/csv; charset=utf-8", newCSVExporter},
+	"ndjson": {"application/x-ndjson", newNDJSONExporter},
+	"xlsx":   {xlsx.ContentType, newXLSXExporter},
+}
+
+// exportWriteTimeout bounds each write of an export. The deadline is
+// pushed out row by row, so a long download is fine as long as the
+// client keeps reading.
+const exportWriteTimeout = 30 * time.Second
+
+var exportColumns = []string{"id", "name", "description", "price", "currency", "stock", "created_at", "updated_at"}
+
+// @Summary      Export products
+// @Description  Download every product matching the listing filters as CSV, JSON Lines or an Excel workbook. Takes the same sort and filter parameters as GET /products.
+// @Tags         products
+// @Produce      octet-stream
+// @Param        format    query string true  "File format" Enums(csv, ndjson, xlsx)
+// @Param        sort      query string false "Sort field" Enums(created_at, updated_at, name, price, stock)
+// @Param        order     query string false "Sort direction (default desc)" Enums(asc, desc)
+// @Param        currency  query string false "ISO 4217 currency code"
+// @Param        min_price query string false "Minimum price"
+// @Param        max_price query string false "Maximum price"
+// @Param        min_stock query int    false "Minimum stock"
+// @Param        max_stock query int    false "Maximum stock"
+// @Param        q         query string false "Name prefix (case-insensitive)"
+// @Param        category  query string false "Category ID; includes its subcategories"
+// @Param        tag       query []string false "Tag name (repeatable)" collectionFormat(multi)
+// @Param        tag_match query string false "Match any (default) or all of the tags" Enums(any, all)
+// @Success      200 {file}   file
+// @Failure      400 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/export [get]
+func (h *ProductHandler) Export(w http.ResponseWriter, r *http.Request) {
+	formatName := r.URL.Query().Get("format")
+	format, ok := exportFormats[formatName]
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "format must be csv, ndjson or xlsx")
+		return
+	}
+
+	input, errs := parseListProductsQuery(r)
+	if errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	// a full dump can take longer than the server's write timeout, so
+	// the deadline moves with every row instead
+	rc := http.NewResponseController(w)
+	extendDeadline := func() error {
+		return rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	}
+	if err := extendDeadline(); err != nil {
+		slog.Warn("could not set write deadline for export", "error", err)
+		extendDeadline = func() error { return nil }
+	}
+
+	// the file is only started once the first row arrives, so a query
+	// that fails up front still gets a proper error response
+	var exporter productExporter
+	start := func() error {
+		filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102-150405"), formatName)
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+		var err error
+		exporter, err = format.open(w)
+		return err
+	}
+
+	err := h.productService.Export(r.Context(), h.scope(r), input, func(p db.Product) error {
+		if err := extendDeadline(); err != nil {
+			return err
+		}
+		if exporter == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return exporter.Write(p)
+	})
+	if err == nil && exporter == nil {
+		// no rows is still a valid, empty file
+		err = start()
+	}
+	if err == nil {
+		err = exporter.Close()
+	}
+	if err != nil {
+		if exporter == nil {
+			response.Error(w, http.StatusInternalServerError, "could not export products")
+			return
+		}
+		// headers are gone; cutting the connection is the only way to
+		// tell the client its download is incomplete
+		slog.Error("product export failed mid-stream", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) (productExporter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return &csvExporter{w: cw}, nil
+}
+
+func (e *csvExporter) Write(p db.Product) error {
+	return e.w.Write([]string{
+		p.ID.String(),
+		p.Name,
+		p.Description.String,
+		p.Price.String(),
+		p.Currency,
+		strconv.Itoa(int(p.Stock)),
+		p.CreatedAt.UTC().Format(time.RFC3339),
+		p.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// exportedProduct is the NDJSON shape: price is a real JSON number and
+// a missing description is null, rather than db.Product's wrappers
+type exportedProduct struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description *string     `json:"description"`
+	Price       json.Number `json:"price"`
+	Currency    string      `json:"currency"`
+	Stock       int32       `json:"stock"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONExporter(w io.Writer) (productExporter, error) {
+	return &ndjsonExporter{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonExporter) Write(p db.Product) error {
+	out := exportedProduct{
+		ID:        p.ID.String(),
+		Name:      p.Name,
+		Price:     json.Number(p.Price.String()),
+		Currency:  p.Currency,
+		Stock:     p.Stock,
+		CreatedAt: p.CreatedAt.UTC(),
+		UpdatedAt: p.UpdatedAt.UTC(),
+	}
+	if p.Description.Valid {
+		out.Description = &p.Description.String
+	}
+	return e.enc.Encode(out)
+}
+
+func (e *ndjsonExporter) Close() error {
+	return nil
+}
+
+type xlsxExporter struct {
+	w *xlsx.Writer
+}
+
+func newXLSXExporter(w io.Writer) (productExporter, error) {
+	xw, err := xlsx.NewWriter(w, "Products")
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]xlsx.Cell, len(exportColumns))
+	for i, name := range exportColumns {
+		header[i] = xlsx.String(name)
+	}
+	if err := xw.WriteRow(header...); err != nil {
+		return nil, err
+	}
+
+	return &xlsxExporter{w: xw}, nil
+}
+
+func (e *xlsxExporter) Write(p db.Product) error {
+	return e.w.WriteRow(
+		xlsx.String(p.ID.String()),
+		xlsx.String(p.Name),
+		xlsx.String(p.Description.String),
+		xlsx.Number(p.Price.String()),
+		xlsx.String(p.Currency),
+		xlsx.Number(strconv.Itoa(int(p.Stock))),
+		xlsx.String(p.CreatedAt.UTC().Format(time.RFC3339)),
+		xlsx.String(p.UpdatedAt.UTC().Format(time.RFC3339)),
+	)
+}
+
+func (e *xlsxExporter) Close() error {
+	return e.w.Close()
+}
@@ -3,9 +3,15 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
 
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/jsonpatch"
 	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
 	"github.com/falasefemi2/goreact-boilerplate/internal/response"
 	"github.com/falasefemi2/goreact-boilerplate/internal/service"
 	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
@@ -14,24 +20,47 @@ import (
 
 type ProductHandler struct {
 	productService *service.ProductService
+	imageService   *service.ImageService
+	allUsers       bool
 }
 
-func NewProductHandler(productService *service.ProductService) *ProductHandler {
-	return &ProductHandler{productService: productService}
+func NewProductHandler(productService *service.ProductService, imageService *service.ImageService) *ProductHandler {
+	return &ProductHandler{productService: productService, imageService: imageService}
 }
 
-type createProductRequest struct {
-	Name        string `json:"name"        validate:"required,min=1,max=255"`
-	Description string `json:"description"`
-	Price       string `json:"price"       validate:"required"`
-	Stock       int32  `json:"stock"       validate:"min=0"`
+// NewAdminProductHandler serves the same endpoints without the per-user
+// filter. Mount it only behind RequirePermission(rbac.PermManageAnyProduct).
+func NewAdminProductHandler(productService *service.ProductService, imageService *service.ImageService) *ProductHandler {
+	return &ProductHandler{productService: productService, imageService: imageService, allUsers: true}
 }
 
-type updateProductRequest struct {
-	Name        string `json:"name"`
+func (h *ProductHandler) scope(r *http.Request) service.Scope {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	if h.allUsers {
+		return service.AdminScope(userID)
+	}
+	return service.OwnerScope(userID)
+}
+
+const maxSearchQueryLen = 256
+
+// maxPatchAttempts bounds how often a PATCH without If-Match is
+// reapplied when the product keeps changing underneath it
+const maxPatchAttempts = 3
+
+// maxPatchBytes caps a PATCH body, which is read whole before it's
+// applied
+const maxPatchBytes = 1 << 20
+
+type createProductRequest struct {
+	Name        string `json:"name"        validate:"required,min=1,max=255"`
 	Description string `json:"description"`
-	Price       string `json:"price"`
-	Stock       int32  `json:"stock"`
+	Price       string `json:"price"       validate:"required,decimal=10 2"`
+	// Currency is an ISO 4217 code; it defaults to USD
+	Currency string `json:"currency" validate:"omitempty,currency"`
+	Stock    int32  `json:"stock"    validate:"min=0"`
+	// IsPublished lists the product in the public catalog
+	IsPublished bool `json:"is_published"`
 }
 
 // @Summary      Create product
@@ -43,11 +72,10 @@ type updateProductRequest struct {
 // @Success 201 {object} ProductResponse
 // @Success 200 {array}  ProductResponse
 // @Failure      400 {object} map[string]string
+// @Failure      422 {object} map[string]string
 // @Security     CookieAuth
 // @Router       /api/v1/products [post]
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middleware.UserIDKey).(string)
-
 	var req createProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid request body")
@@ -59,97 +87,391 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.productService.Create(r.Context(), userID, service.CreateProductInput{
+	product, err := h.productService.Create(r.Context(), h.scope(r), service.CreateProductInput{
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		// validated, so it parses
+		Price:       money.MustParse(req.Price),
+		Currency:    req.Currency,
 		Stock:       req.Stock,
+		IsPublished: req.IsPublished,
 	})
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "could not create product")
+		writeProductError(w, err, "could not create product")
 		return
 	}
 
+	w.Header().Set("ETag", productETag(product))
 	response.JSON(w, http.StatusCreated, product)
 }
 
 // @Summary      List products
-// @Description  Get all products for the current user
+// @Description  Keyset-paginated products for the current user. Pass meta.next_cursor back as ?cursor= to get the next page.
 // @Tags         products
 // @Produce      json
-// @Success 201 {object} ProductResponse
+// @Param        limit     query int    false "Page size (default 20, max 100)"
+// @Param        cursor    query string false "Opaque cursor from the previous page"
+// @Param        sort      query string false "Sort field" Enums(created_at, updated_at, name, price, stock)
+// @Param        order     query string false "Sort direction (default desc)" Enums(asc, desc)
+// @Param        currency  query string false "ISO 4217 currency code"
+// @Param        min_price query string false "Minimum price"
+// @Param        max_price query string false "Maximum price"
+// @Param        min_stock query int    false "Minimum stock"
+// @Param        max_stock query int    false "Maximum stock"
+// @Param        q         query string false "Name prefix (case-insensitive)"
+// @Param        category  query string false "Category ID; includes its subcategories"
+// @Param        tag       query []string false "Tag name (repeatable)" collectionFormat(multi)
+// @Param        tag_match query string false "Match any (default) or all of the tags" Enums(any, all)
+// @Param        include   query string false "Embed each product's variants" Enums(variants)
 // @Success 200 {array}  ProductResponse
+// @Failure 400 {object} map[string]string
 // @Security     CookieAuth
 // @Router       /api/v1/products [get]
 func (h *ProductHandler) List(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middleware.UserIDKey).(string)
+	input, errs := parseListProductsQuery(r)
+	if errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
 
-	products, err := h.productService.List(r.Context(), userID)
+	page, err := h.productService.List(r.Context(), h.scope(r), input)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			response.Error(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
 		response.Error(w, http.StatusInternalServerError, "could not fetch products")
 		return
 	}
 
-	response.JSON(w, http.StatusOK, products)
+	var products any = page.Products
+	switch {
+	case page.WithVariants != nil:
+		products = page.WithVariants
+	case page.Products == nil:
+		products = []db.Product{}
+	}
+
+	meta := response.CursorMeta{Limit: input.Limit}
+	if page.NextCursor != "" {
+		meta.NextCursor = &page.NextCursor
+	}
+	response.JSONWithMeta(w, http.StatusOK, products, meta)
 }
 
-func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middleware.UserIDKey).(string)
-	productID := chi.URLParam(r, "id")
+// @Summary      Search products
+// @Description  Full-text search over name and description, best matches first. All terms must match; use "quotes" for phrases and a trailing * for prefixes. headline is HTML: the product's text escaped, with matches wrapped in <mark> tags.
+// @Tags         products
+// @Produce      json
+// @Param        q         query string true  "Search terms"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success 200 {array}  SearchResultResponse
+// @Failure 400 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/search [get]
+func (h *ProductHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" || len(query) > maxSearchQueryLen {
+		response.Error(w, http.StatusBadRequest, "q is required and must be at most 256 characters")
+		return
+	}
+
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
 
-	product, err := h.productService.GetByID(r.Context(), userID, productID)
+	result, err := h.productService.Search(r.Context(), h.scope(r), query, page)
 	if err != nil {
-		if errors.Is(err, service.ErrProductNotFound) {
-			response.Error(w, http.StatusNotFound, "product not found")
+		if errors.Is(err, service.ErrEmptySearchQuery) {
+			response.Error(w, http.StatusBadRequest, "search query has no searchable terms")
 			return
 		}
-		response.Error(w, http.StatusInternalServerError, "could not fetch product")
+		response.Error(w, http.StatusInternalServerError, "could not search products")
+		return
+	}
+
+	results := result.Results
+	if results == nil {
+		results = []db.SearchProductsRow{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, results, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Get product
+// @Description  Responds with an ETag; send it back as If-None-Match to get a 304 when nothing changed
+// @Tags         products
+// @Produce      json
+// @Param        id            path   string true  "Product ID"
+// @Param        If-None-Match header string false "ETag from a previous GET"
+// @Success 200 {object} ProductResponse
+// @Success 304
+// @Failure 404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id} [get]
+func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+
+	product, err := h.productService.GetByID(r.Context(), h.scope(r), productID)
+	if err != nil {
+		writeProductError(w, err, "could not fetch product")
+		return
+	}
+
+	etag := productETag(product)
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, product)
 }
 
+// @Summary      Replace product
+// @Description  Overwrite every editable field. Use PATCH to change only some.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id       path   string          true  "Product ID"
+// @Param        If-Match header string          false "ETag from a previous GET"
+// @Param        request  body   productDocument true  "Product data"
+// @Success 200 {object} ProductResponse
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id} [put]
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middleware.UserIDKey).(string)
 	productID := chi.URLParam(r, "id")
 
-	var req updateProductRequest
+	var req productDocument
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	product, err := h.productService.Update(r.Context(), userID, productID, service.UpdateProductInput{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Stock:       req.Stock,
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	expected, ok := h.ifMatch(w, r, productID)
+	if !ok {
+		return
+	}
+
+	description := ""
+	if req.Description != nil {
+		description = *req.Description
+	}
+	// validated, so it parses
+	price := money.MustParse(req.Price)
+	var currency *string
+	if req.Currency != "" {
+		currency = &req.Currency
+	}
+
+	product, err := h.productService.Update(r.Context(), h.scope(r), productID, service.UpdateProductInput{
+		Name:            &req.Name,
+		Description:     &description,
+		Price:           &price,
+		Currency:        currency,
+		Stock:           req.Stock,
+		IsPublished:     req.IsPublished,
+		ExpectedVersion: expected,
 	})
 	if err != nil {
-		if errors.Is(err, service.ErrProductNotFound) {
-			response.Error(w, http.StatusNotFound, "product not found")
-			return
-		}
-		response.Error(w, http.StatusInternalServerError, "could not update product")
+		writeProductError(w, err, "could not update product")
 		return
 	}
 
+	w.Header().Set("ETag", productETag(product))
 	response.JSON(w, http.StatusOK, product)
 }
 
-func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middleware.UserIDKey).(string)
+// @Summary      Patch product
+// @Description  Partial update. Send a JSON Merge Patch (RFC 7396, application/merge-patch+json or application/json) or a JSON Patch (RFC 6902, application/json-patch+json). Fields left out are unchanged; in a merge patch, null clears the description. The patched product is validated like a PUT body.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id       path   string true  "Product ID"
+// @Param        If-Match header string false "ETag from a previous GET"
+// @Param        request  body   object true  "Merge patch or JSON Patch document"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id} [patch]
+func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "id")
 
-	if err := h.productService.Delete(r.Context(), userID, productID); err != nil {
-		if errors.Is(err, service.ErrProductNotFound) {
-			response.Error(w, http.StatusNotFound, "product not found")
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	apply, ok := patchers[mediaType]
+	if !ok {
+		w.Header().Set("Accept-Patch", acceptPatch)
+		response.Error(w, http.StatusUnsupportedMediaType, "unsupported patch format")
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			response.Error(w, http.StatusRequestEntityTooLarge, "patch document is too large")
+			return
+		}
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+
+	// Without If-Match, a concurrent write between reading the product
+	// and saving it just means the patch is applied again on top of it
+	for attempt := 1; ; attempt++ {
+		current, err := h.productService.GetByID(r.Context(), h.scope(r), productID)
+		if err != nil {
+			writeProductError(w, err, "could not fetch product")
+			return
+		}
+
+		if ifMatch != "" && !etagMatches(ifMatch, productETag(current), false) {
+			response.Error(w, http.StatusPreconditionFailed, "product has been modified")
+			return
+		}
+
+		doc, err := json.Marshal(toProductDocument(current))
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "could not update product")
+			return
+		}
+
+		patched, err := apply(doc, patch)
+		if err != nil {
+			switch {
+			case errors.Is(err, jsonpatch.ErrConflict):
+				response.Error(w, http.StatusConflict, err.Error())
+			case errors.Is(err, jsonpatch.ErrInvalidPatch):
+				response.Error(w, http.StatusBadRequest, err.Error())
+			default:
+				response.Error(w, http.StatusBadRequest, "invalid patch document")
+			}
+			return
+		}
+
+		next, errs := decodeProductDocument(patched)
+		if errs != nil {
+			response.ValidationError(w, errs)
+			return
+		}
+
+		input, changed := diffProduct(current, next)
+		if !changed {
+			w.Header().Set("ETag", productETag(current))
+			response.JSON(w, http.StatusOK, current)
+			return
+		}
+		input.ExpectedVersion = &current.Version
+
+		product, err := h.productService.Update(r.Context(), h.scope(r), productID, input)
+		if errors.Is(err, service.ErrVersionMismatch) && ifMatch == "" {
+			if attempt < maxPatchAttempts {
+				continue
+			}
+			// there was no precondition to fail; the product just
+			// wouldn't hold still long enough to patch
+			response.Error(w, http.StatusConflict, "product is being modified concurrently, try again")
+			return
+		}
+		if err != nil {
+			writeProductError(w, err, "could not update product")
 			return
 		}
-		response.Error(w, http.StatusInternalServerError, "could not delete product")
+
+		w.Header().Set("ETag", productETag(product))
+		response.JSON(w, http.StatusOK, product)
+		return
+	}
+}
+
+// @Summary      Delete product
+// @Description  Moves the product to the trash. Restore it with POST /products/{id}/restore, or purge it with DELETE /products/trash/{id}.
+// @Tags         products
+// @Param        id       path   string true  "Product ID"
+// @Param        If-Match header string false "ETag from a previous GET"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id} [delete]
+func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+
+	expected, ok := h.ifMatch(w, r, productID)
+	if !ok {
+		return
+	}
+
+	if err := h.productService.Delete(r.Context(), h.scope(r), productID, expected); err != nil {
+		writeProductError(w, err, "could not delete product")
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
+
+// ifMatch checks the If-Match header against the product's current
+// ETag and returns the version the write must still find, nil if
+// there's no header or it's "*". On a mismatch it writes the error
+// response and returns false.
+func (h *ProductHandler) ifMatch(w http.ResponseWriter, r *http.Request, productID string) (*int32, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return nil, true
+	}
+
+	current, err := h.productService.GetByID(r.Context(), h.scope(r), productID)
+	if err != nil {
+		writeProductError(w, err, "could not fetch product")
+		return nil, false
+	}
+
+	if !etagMatches(header, productETag(current), false) {
+		response.Error(w, http.StatusPreconditionFailed, "product has been modified")
+		return nil, false
+	}
+
+	// the write re-checks the version, in case it changes after this read
+	return &current.Version, true
+}
+
+func writeProductError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		response.Error(w, http.StatusNotFound, "product not found")
+	case errors.Is(err, service.ErrVersionMismatch):
+		response.Error(w, http.StatusPreconditionFailed, "product has been modified")
+	case errors.Is(err, service.ErrInvalidPrice):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "price", Message: "must not be negative or have more decimal places than its currency"},
+		})
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// multipartOverhead is room for the boundaries and part headers around
+// the image itself
+const multipartOverhead = 64 << 10
+
+// @Summary      Upload product image
+// @Description  Upload a JPEG, PNG or GIF as the "image" field of a multipart form. The type is detected from the file's contents. A thumbnail is generated, and both are returned as signed links that expire.
+// @Tags         products
+// @Accept       mpfd
+// @Produce      json
+// @Param        id    path     string true "Product ID"
+// @Param        image formData file   true "Image file"
+// @Success      201 {object} service.ProductImage
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Failure      415 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/images [post]
+func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	limit := h.imageService.MaxBytes()
+	if r.ContentLength > limit+multipartOverhead {
+		writeImageError(w, service.ErrImageTooLarge, "")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "expected a multipart/form-data upload")
+		return
+	}
+
+	// stream the parts rather than ParseMultipartForm, which would spool
+	// the upload to disk first
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			response.Error(w, http.StatusBadRequest, `upload has no "image" field`)
+			return
+		}
+		if err != nil {
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				writeImageError(w, service.ErrImageTooLarge, "")
+				return
+			}
+			response.Error(w, http.StatusBadRequest, "invalid multipart body")
+			return
+		}
+		if part.FormName() != "image" {
+			part.Close()
+			continue
+		}
+
+		image, err := h.imageService.Upload(r.Context(), h.scope(r), chi.URLParam(r, "id"), part)
+		part.Close()
+		if err != nil {
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				err = service.ErrImageTooLarge
+			}
+			writeImageError(w, err, "could not upload image")
+			return
+		}
+
+		response.JSON(w, http.StatusCreated, image)
+		return
+	}
+}
+
+// @Summary      List product images
+// @Description  Images with freshly signed links, oldest first
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {array}  service.ProductImage
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/images [get]
+func (h *ProductHandler) Images(w http.ResponseWriter, r *http.Request) {
+	images, err := h.imageService.List(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeImageError(w, err, "could not fetch images")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, images)
+}
+
+// @Summary      Delete product image
+// @Tags         products
+// @Param        id       path string true "Product ID"
+// @Param        image_id path string true "Image ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/images/{image_id} [delete]
+func (h *ProductHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	err := h.imageService.Delete(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "image_id"))
+	if err != nil {
+		writeImageError(w, err, "could not delete image")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary      Download image
+// @Description  Serves an image or thumbnail through a signed link from the image endpoints. No session is needed; the signature is the credential.
+// @Tags         products
+// @Produce      image/jpeg,image/png,image/gif
+// @Param        key       path  string true "Blob key"
+// @Param        expires   query int    true "Expiry (Unix seconds)"
+// @Param        signature query string true "Link signature"
+// @Success      200 {file}   file
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /api/v1/images/{key} [get]
+func (h *ProductHandler) ServeImage(w http.ResponseWriter, r *http.Request) {
+	blob, expires, err := h.imageService.Open(r.Context(), chi.URLParam(r, "*"), r.URL.Query())
+	if err != nil {
+		writeImageError(w, err, "could not fetch image")
+		return
+	}
+	defer blob.Body.Close()
+
+	w.Header().Set("Content-Type", blob.ContentType)
+	if blob.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	}
+	// cacheable for as long as the link works, but only by the browser
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(time.Until(expires).Seconds())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, blob.Body); err != nil {
+		slog.Warn("image download interrupted", "error", err)
+	}
+}
+
+func writeImageError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		response.Error(w, http.StatusNotFound, "product not found")
+	case errors.Is(err, service.ErrImageNotFound):
+		response.Error(w, http.StatusNotFound, "image not found")
+	case errors.Is(err, service.ErrImageLinkInvalid):
+		response.Error(w, http.StatusForbidden, "image link is invalid or has expired")
+	case errors.Is(err, service.ErrImageTooLarge):
+		response.Error(w, http.StatusRequestEntityTooLarge, "image is too large")
+	case errors.Is(err, service.ErrUnsupportedImage):
+		response.Error(w, http.StatusUnsupportedMediaType, "image must be a JPEG, PNG or GIF")
+	case errors.Is(err, service.ErrImageDimensions):
+		response.Error(w, http.StatusRequestEntityTooLarge, "image dimensions are too large")
+	case errors.Is(err, service.ErrTooManyImages):
+		response.Error(w, http.StatusConflict, "product has too many images")
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
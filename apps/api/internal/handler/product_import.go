@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxImportBytes = 50 << 20
+	maxImportRows  = 100_000
+	// imports bigger than this run in the background
+	syncImportMaxRows = 1000
+)
+
+var errTooManyImportRows = fmt.Errorf("imports are limited to %d rows", maxImportRows)
+
+// importProductRow is one line of an import, in either format
+type importProductRow struct {
+	Name        string `json:"name"        validate:"required,min=1,max=255"`
+	Description string `json:"description"`
+	Price       string `json:"price"       validate:"required,decimal=10 2"`
+	Currency    string `json:"currency"    validate:"omitempty,currency"`
+	Stock       int32  `json:"stock"       validate:"min=0"`
+}
+
+// importParsers maps upload content types to their row parsers
+var importParsers = map[string]func(io.Reader) ([]service.ImportRow, []service.ImportRowError, error){
+	"text/csv":             parseCSVImport,
+	"application/x-ndjson": parseNDJSONImport,
+	"application/jsonl":    parseNDJSONImport,
+}
+
+// @Summary      Import products
+// @Description  Bulk-create products from a CSV (text/csv, with a name,description,price,stock header, plus an optional currency column) or JSON Lines (application/x-ndjson) upload. mode=atomic inserts all rows or none; mode=best_effort inserts the valid ones. Up to 1000 rows are imported immediately; bigger uploads, or async=true, return 202 and a job to poll.
+// @Tags         products
+// @Accept       plain
+// @Produce      json
+// @Param        mode  query string false "atomic (default) or best_effort" Enums(atomic, best_effort)
+// @Param        async query bool   false "Always run in the background"
+// @Success 200 {object} service.ImportReport
+// @Success 202 {object} ProductImportResponse
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} service.ImportReport
+// @Security     CookieAuth
+// @Router       /api/v1/products/import [post]
+func (h *ProductHandler) Import(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = service.ImportAtomic
+	}
+	if mode != service.ImportAtomic && mode != service.ImportBestEffort {
+		response.Error(w, http.StatusBadRequest, "mode must be atomic or best_effort")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	parse, ok := importParsers[mediaType]
+	if !ok {
+		response.Error(w, http.StatusUnsupportedMediaType, "upload must be text/csv or application/x-ndjson")
+		return
+	}
+
+	rows, invalid, err := parse(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooBig), errors.Is(err, errTooManyImportRows):
+			response.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf(
+				"imports are limited to %d rows and %d MB", maxImportRows, maxImportBytes>>20))
+		default:
+			response.Error(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	if len(rows)+len(invalid) == 0 {
+		response.Error(w, http.StatusBadRequest, "upload has no rows")
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" || len(rows)+len(invalid) > syncImportMaxRows {
+		job, err := h.productService.StartImport(r.Context(), h.scope(r), mode, rows, invalid)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "could not start import")
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/products/imports/"+job.ID.String())
+		response.JSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	report, err := h.productService.Import(r.Context(), h.scope(r), mode, rows, invalid)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not import products")
+		return
+	}
+
+	status := http.StatusOK
+	if report.Status == service.ImportFailed {
+		status = http.StatusUnprocessableEntity
+	}
+	response.JSON(w, status, report)
+}
+
+// @Summary      Get import
+// @Description  Progress and, once finished, the per-row error report of a background import
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Import ID"
+// @Success 200 {object} ProductImportResponse
+// @Failure 404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/imports/{id} [get]
+func (h *ProductHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.productService.GetImport(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrImportNotFound) {
+			response.Error(w, http.StatusNotFound, "import not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "could not fetch import")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, job)
+}
+
+// checkImportRow validates a decoded row, turning it into either an
+// ImportRow or the errors that keep it out
+func checkImportRow(line int, row importProductRow) (service.ImportRow, []service.ImportRowError) {
+	if errs := appvalidator.Validate(row); errs != nil {
+		rowErrs := make([]service.ImportRowError, 0, len(errs))
+		for _, e := range errs {
+			rowErrs = append(rowErrs, service.ImportRowError{Line: line, Field: e.Field, Message: e.Message})
+		}
+		return service.ImportRow{}, rowErrs
+	}
+
+	product := service.CreateProductInput{
+		Name:        row.Name,
+		Description: row.Description,
+		// validated, so it parses
+		Price:    money.MustParse(row.Price),
+		Currency: row.Currency,
+		Stock:    row.Stock,
+	}
+	currency := product.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if err := service.CheckPrice(product.Price, currency); err != nil {
+		return service.ImportRow{}, []service.ImportRowError{
+			{Line: line, Field: "price", Message: "must not be negative or have more decimal places than its currency"},
+		}
+	}
+
+	return service.ImportRow{Line: line, Product: product}, nil
+}
+
+// parseCSVImport reads a CSV upload. The header names the columns, in
+// any order; description, currency and stock may be left out.
+func parseCSVImport(body io.Reader) ([]service.ImportRow, []service.ImportRowError, error) {
+	reader := csv.NewReader(body)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "description", "price", "currency", "stock":
+			columns[name] = i
+		default:
+			return nil, nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header is missing the %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var (
+		rows    []service.ImportRow
+		invalid []service.ImportRowError
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && !errors.Is(err, csv.ErrFieldCount) {
+				return nil, nil, fmt.Errorf("malformed CSV: %w", err)
+			}
+			if parseErr == nil {
+				return nil, nil, err
+			}
+			invalid = append(invalid, service.ImportRowError{Line: parseErr.Line, Message: "wrong number of fields"})
+			continue
+		}
+		if len(rows)+len(invalid) >= maxImportRows {
+			return nil, nil, errTooManyImportRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importProductRow{
+			Name:        field(record, "name"),
+			Description: field(record, "description"),
+			Price:       field(record, "price"),
+			Currency:    field(record, "currency"),
+		}
+		if stock := field(record, "stock"); stock != "" {
+			n, err := strconv.ParseInt(stock, 10, 32)
+			if err != nil {
+				invalid = append(invalid, service.ImportRowError{Line: line, Field: "stock", Message: "must be a whole number"})
+				continue
+			}
+			row.Stock = int32(n)
+		}
+
+		parsed, errs := checkImportRow(line, row)
+		if errs != nil {
+			invalid = append(invalid, errs[0])
+			continue
+		}
+		rows = append(rows, parsed)
+	}
+
+	return rows, invalid, nil
+}
+
+// parseNDJSONImport reads one JSON object per line; blank lines are
+// skipped
+func parseNDJSONImport(body io.Reader) ([]service.ImportRow, []service.ImportRowError, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	var (
+		rows    []service.ImportRow
+		invalid []service.ImportRowError
+	)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if len(rows)+len(invalid) >= maxImportRows {
+			return nil, nil, errTooManyImportRows
+		}
+
+		var row importProductRow
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&row); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				invalid = append(invalid, service.ImportRowError{
+					Line:    line,
+					Field:   typeErr.Field,
+					Message: fmt.Sprintf("must not be a %s", typeErr.Value),
+				})
+			} else {
+				invalid = append(invalid, service.ImportRowError{Line: line, Message: "not a valid product object"})
+			}
+			continue
+		}
+
+		parsed, errs := checkImportRow(line, row)
+		if errs != nil {
+			invalid = append(invalid, errs[0])
+			continue
+		}
+		rows = append(rows, parsed)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, nil, errors.New("a line is longer than 1 MB")
+		}
+		return nil, nil, err
+	}
+
+	return rows, invalid, nil
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/jsonpatch"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+)
+
+// productDocument is the editable part of a product: the body of a PUT,
+// and the document a PATCH is applied to. A null or empty description
+// means none; an empty currency or a missing is_published leaves it as
+// it is.
+type productDocument struct {
+	Name        string  `json:"name"         validate:"required,min=1,max=255"`
+	Description *string `json:"description"`
+	Price       string  `json:"price"        validate:"required,decimal=10 2"`
+	Currency    string  `json:"currency"     validate:"omitempty,currency"`
+	Stock       *int32  `json:"stock"        validate:"required,min=0"`
+	IsPublished *bool   `json:"is_published"`
+}
+
+func toProductDocument(p db.Product) productDocument {
+	doc := productDocument{
+		Name:        p.Name,
+		Price:       p.Price.String(),
+		Currency:    p.Currency,
+		Stock:       &p.Stock,
+		IsPublished: &p.IsPublished,
+	}
+	if p.Description.Valid {
+		doc.Description = &p.Description.String
+	}
+	return doc
+}
+
+// patchers maps PATCH content types to how they're applied.
+// Plain application/json is treated as a merge patch.
+var patchers = map[string]func(doc, patch []byte) ([]byte, error){
+	jsonpatch.MergePatchContentType: jsonpatch.MergePatch,
+	"application/json":              jsonpatch.MergePatch,
+	jsonpatch.JSONPatchContentType:  jsonpatch.Apply,
+}
+
+// acceptPatch is the Accept-Patch header value advertising patchers
+var acceptPatch = strings.Join([]string{
+	jsonpatch.MergePatchContentType,
+	jsonpatch.JSONPatchContentType,
+}, ", ")
+
+// decodeProductDocument strictly decodes a patched document. Type
+// mismatches and unknown fields come back as validation errors, since
+// they're the client's patch producing a product that can't exist.
+func decodeProductDocument(data []byte) (productDocument, []appvalidator.ValidationError) {
+	var doc productDocument
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(&doc)
+	if err == nil {
+		if errs := appvalidator.Validate(doc); errs != nil {
+			return doc, errs
+		}
+		return doc, nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return doc, []appvalidator.ValidationError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must not be a %s", typeErr.Value),
+		}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return doc, []appvalidator.ValidationError{{Field: field, Message: "unknown or read-only field"}}
+	default:
+		return doc, []appvalidator.ValidationError{{Field: "", Message: "must be a JSON object"}}
+	}
+}
+
+// diffProduct turns the patched document into an update that only
+// touches fields that actually changed
+func diffProduct(before db.Product, after productDocument) (service.UpdateProductInput, bool) {
+	var (
+		input   service.UpdateProductInput
+		changed bool
+	)
+
+	if after.Name != before.Name {
+		input.Name = &after.Name
+		changed = true
+	}
+
+	description := ""
+	if after.Description != nil {
+		description = *after.Description
+	}
+	if description != before.Description.String {
+		input.Description = &description
+		changed = true
+	}
+
+	// validated, so it parses
+	if price := money.MustParse(after.Price); !price.Equal(before.Price) {
+		input.Price = &price
+		changed = true
+	}
+
+	if after.Currency != "" && after.Currency != before.Currency {
+		input.Currency = &after.Currency
+		changed = true
+	}
+
+	if *after.Stock != before.Stock {
+		input.Stock = after.Stock
+		changed = true
+	}
+
+	if after.IsPublished != nil && *after.IsPublished != before.IsPublished {
+		input.IsPublished = after.IsPublished
+		changed = true
+	}
+
+	return input, changed
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type schedulePriceRequest struct {
+	Price string `json:"price" validate:"required,decimal=10 2"`
+	// EffectiveAt is an RFC 3339 time in the future
+	EffectiveAt time.Time `json:"effective_at" validate:"required"`
+}
+
+// @Summary      Price history
+// @Description  Every price the product has had, newest first
+// @Tags         products
+// @Produce      json
+// @Param        id        path  string true  "Product ID"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success      200 {array}  PriceHistoryResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/prices [get]
+func (h *ProductHandler) PriceHistory(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.productService.PriceHistory(r.Context(), h.scope(r), chi.URLParam(r, "id"), page)
+	if err != nil {
+		writeProductError(w, err, "could not fetch price history")
+		return
+	}
+
+	entries := result.Entries
+	if entries == nil {
+		entries = []db.PriceHistory{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, entries, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      List scheduled price changes
+// @Description  The product's scheduled price changes in the order they take effect: pending, applied, and skipped because the product changed currency
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {array}  ScheduledPriceChangeResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/prices/scheduled [get]
+func (h *ProductHandler) ScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	changes, err := h.productService.ScheduledPriceChanges(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeProductError(w, err, "could not fetch scheduled price changes")
+		return
+	}
+
+	if changes == nil {
+		changes = []db.ScheduledPriceChange{}
+	}
+	response.JSON(w, http.StatusOK, changes)
+}
+
+// @Summary      Schedule price change
+// @Description  Set a new price at a future time, in the product's current currency. It's applied within a minute of effective_at. For a sale, schedule the sale price at its start and the regular price at its end.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id      path string               true "Product ID"
+// @Param        request body schedulePriceRequest true "Price change"
+// @Success      201 {object} ScheduledPriceChangeResponse
+// @Failure      404 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/prices/scheduled [post]
+func (h *ProductHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	var req schedulePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	change, err := h.productService.SchedulePriceChange(r.Context(), h.scope(r), chi.URLParam(r, "id"), service.SchedulePriceChangeInput{
+		// validated above
+		Price:       money.MustParse(req.Price),
+		EffectiveAt: req.EffectiveAt,
+	})
+	if err != nil {
+		writePriceChangeError(w, err, "could not schedule price change")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, change)
+}
+
+// @Summary      Cancel scheduled price change
+// @Description  Call off a price change that hasn't been applied yet
+// @Tags         products
+// @Param        id        path string true "Product ID"
+// @Param        change_id path string true "Scheduled change ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/prices/scheduled/{change_id} [delete]
+func (h *ProductHandler) CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	err := h.productService.CancelPriceChange(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "change_id"))
+	if err != nil {
+		writePriceChangeError(w, err, "could not cancel price change")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func writePriceChangeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPriceChangeNotFound):
+		response.Error(w, http.StatusNotFound, "scheduled price change not found")
+	case errors.Is(err, service.ErrPriceChangeInPast):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "effective_at", Message: "must be in the future"},
+		})
+	default:
+		writeProductError(w, err, fallback)
+	}
+}
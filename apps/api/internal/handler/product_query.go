@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/google/uuid"
+)
+
+// matches what NUMERIC(10, 2) will accept
+var priceParam = regexp.MustCompile(`^\d{1,8}(\.\d{1,2})?$`)
+
+const maxTagFilters = 20
+
+// parseListProductsQuery turns listing query parameters into a service
+// request, collecting every bad parameter instead of stopping at the first
+func parseListProductsQuery(r *http.Request) (service.ListProductsInput, []appvalidator.ValidationError) {
+	q := r.URL.Query()
+	var errs []appvalidator.ValidationError
+	invalid := func(field, message string) {
+		errs = append(errs, appvalidator.ValidationError{Field: field, Message: message})
+	}
+
+	input := service.ListProductsInput{
+		Limit:      service.DefaultPageSize,
+		Cursor:     q.Get("cursor"),
+		Sort:       "created_at",
+		Desc:       true,
+		Currency:   q.Get("currency"),
+		MinPrice:   q.Get("min_price"),
+		MaxPrice:   q.Get("max_price"),
+		NamePrefix: q.Get("q"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			invalid("limit", "must be a positive integer")
+		} else {
+			input.Limit = min(n, service.MaxPageSize)
+		}
+	}
+
+	if v := q.Get("sort"); v != "" {
+		if !db.ValidProductSort(v) {
+			invalid("sort", "must be one of created_at, updated_at, name, price, stock")
+		}
+		input.Sort = v
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		input.Desc = false
+	default:
+		invalid("order", "must be asc or desc")
+	}
+
+	if input.Currency != "" && !money.ValidCurrency(input.Currency) {
+		invalid("currency", "must be an ISO 4217 currency code")
+	}
+	if input.MinPrice != "" && !priceParam.MatchString(input.MinPrice) {
+		invalid("min_price", "must be a decimal with at most 2 places")
+	}
+	if input.MaxPrice != "" && !priceParam.MatchString(input.MaxPrice) {
+		invalid("max_price", "must be a decimal with at most 2 places")
+	}
+
+	if v := q.Get("category"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			invalid("category", "must be a category ID")
+		}
+		input.CategoryID = uuid.NullUUID{UUID: id, Valid: err == nil}
+	}
+
+	input.Tags = q["tag"]
+	if len(input.Tags) > maxTagFilters {
+		invalid("tag", fmt.Sprintf("at most %d tags can be given", maxTagFilters))
+	}
+	switch q.Get("tag_match") {
+	case "", "any":
+	case "all":
+		input.AllTags = true
+	default:
+		invalid("tag_match", "must be any or all")
+	}
+
+	switch q.Get("include") {
+	case "":
+	case "variants":
+		input.IncludeVariants = true
+	default:
+		invalid("include", "must be variants")
+	}
+
+	input.MinStock = parseStockParam(q.Get("min_stock"), "min_stock", invalid)
+	input.MaxStock = parseStockParam(q.Get("max_stock"), "max_stock", invalid)
+
+	return input, errs
+}
+
+func parseStockParam(v, field string, invalid func(field, message string)) *int32 {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n < 0 {
+		invalid(field, "must be a non-negative integer")
+		return nil
+	}
+	stock := int32(n)
+	return &stock
+}
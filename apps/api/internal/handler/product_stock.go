@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type adjustStockRequest struct {
+	// Delta is added to the stock; negative takes stock away
+	Delta  int32  `json:"delta"  validate:"required,min=-1000000,max=1000000"`
+	Reason string `json:"reason" validate:"required,oneof=restock sale adjustment return"`
+	Note   string `json:"note"   validate:"max=500"`
+}
+
+// @Summary      Adjust stock
+// @Description  Add to or take from a product's stock and record why. Restocks and returns must add stock and sales must take it away. Fails with 409 if stock would go below zero.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id      path string             true "Product ID"
+// @Param        request body adjustStockRequest true "Adjustment"
+// @Success      201 {object} StockMovementResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/stock/adjust [post]
+func (h *ProductHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var req adjustStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	product, movement, err := h.productService.AdjustStock(r.Context(), h.scope(r), chi.URLParam(r, "id"), service.StockAdjustmentInput{
+		Delta:  req.Delta,
+		Reason: req.Reason,
+		Note:   req.Note,
+	})
+	if err != nil {
+		writeStockError(w, err, "could not adjust stock")
+		return
+	}
+
+	// the product's version moved on
+	w.Header().Set("ETag", productETag(product))
+	response.JSON(w, http.StatusCreated, movement)
+}
+
+// @Summary      Adjust variant stock
+// @Description  Add to or take from the stock of a variant that has its own, and record why. The same rules apply as for a product. Fails with 409 if stock would go below zero, or if the variant takes the product's stock.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id         path string             true "Product ID"
+// @Param        variant_id path string             true "Variant ID"
+// @Param        request    body adjustStockRequest true "Adjustment"
+// @Success      201 {object} StockMovementResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants/{variant_id}/stock/adjust [post]
+func (h *ProductHandler) AdjustVariantStock(w http.ResponseWriter, r *http.Request) {
+	var req adjustStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	_, movement, err := h.productService.AdjustVariantStock(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"), service.StockAdjustmentInput{
+		Delta:  req.Delta,
+		Reason: req.Reason,
+		Note:   req.Note,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrVariantStockInherited):
+			response.Error(w, http.StatusConflict, "variant has no stock of its own; adjust the product's")
+		default:
+			writeStockError(w, err, "could not adjust stock")
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, movement)
+}
+
+// @Summary      Stock history
+// @Description  The product's stock movements, newest first. Variants with stock of their own have a history of their own.
+// @Tags         products
+// @Produce      json
+// @Param        id        path  string true  "Product ID"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success      200 {array}  StockMovementResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/stock/movements [get]
+func (h *ProductHandler) StockMovements(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.productService.StockMovements(r.Context(), h.scope(r), chi.URLParam(r, "id"), page)
+	if err != nil {
+		writeProductError(w, err, "could not fetch stock history")
+		return
+	}
+
+	movements := result.Movements
+	if movements == nil {
+		movements = []db.StockMovement{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, movements, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Variant stock history
+// @Description  The stock movements of a variant with stock of its own, newest first
+// @Tags         products
+// @Produce      json
+// @Param        id         path  string true  "Product ID"
+// @Param        variant_id path  string true  "Variant ID"
+// @Param        page       query int    false "Page number (1-based)"
+// @Param        page_size  query int    false "Page size (max 100)"
+// @Success      200 {array}  StockMovementResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants/{variant_id}/stock/movements [get]
+func (h *ProductHandler) VariantStockMovements(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.productService.VariantStockMovements(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"), page)
+	if err != nil {
+		writeVariantError(w, err, "could not fetch stock history")
+		return
+	}
+
+	movements := result.Movements
+	if movements == nil {
+		movements = []db.StockMovement{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, movements, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+func writeStockError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInsufficientStock):
+		response.Error(w, http.StatusConflict, "not enough stock")
+	case errors.Is(err, service.ErrStockDirection):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "delta", Message: "must be positive for restock and return, and negative for sale"},
+		})
+	default:
+		writeVariantError(w, err, fallback)
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type setCategoriesRequest struct {
+	// Categories are category IDs
+	Categories []string `json:"categories" validate:"max=100,dive,uuid"`
+}
+
+type setTagsRequest struct {
+	// Tags are tag names; unknown ones are created
+	Tags []string `json:"tags" validate:"max=50,dive,required,max=50"`
+}
+
+// @Summary      List product categories
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {array}  CategoryResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/categories [get]
+func (h *ProductHandler) Categories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.productService.Categories(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeProductError(w, err, "could not fetch categories")
+		return
+	}
+
+	if categories == nil {
+		categories = []db.Category{}
+	}
+	response.JSON(w, http.StatusOK, categories)
+}
+
+// @Summary      Set product categories
+// @Description  Replace the product's categories. They must belong to the product's owner.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id      path string               true "Product ID"
+// @Param        request body setCategoriesRequest true "Category IDs"
+// @Success      200 {array}  CategoryResponse
+// @Failure      404 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/categories [put]
+func (h *ProductHandler) SetCategories(w http.ResponseWriter, r *http.Request) {
+	var req setCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	categories, err := h.productService.SetCategories(r.Context(), h.scope(r), chi.URLParam(r, "id"), req.Categories)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCategory) {
+			response.ValidationError(w, []appvalidator.ValidationError{
+				{Field: "categories", Message: "unknown category"},
+			})
+			return
+		}
+		writeProductError(w, err, "could not set categories")
+		return
+	}
+
+	if categories == nil {
+		categories = []db.Category{}
+	}
+	response.JSON(w, http.StatusOK, categories)
+}
+
+// @Summary      List product tags
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {array}  TagResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/tags [get]
+func (h *ProductHandler) Tags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.productService.Tags(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeProductError(w, err, "could not fetch tags")
+		return
+	}
+
+	if tags == nil {
+		tags = []db.Tag{}
+	}
+	response.JSON(w, http.StatusOK, tags)
+}
+
+// @Summary      Set product tags
+// @Description  Replace the product's tags by name. Names are matched ignoring case; new ones are created.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id      path string         true "Product ID"
+// @Param        request body setTagsRequest true "Tag names"
+// @Success      200 {array}  TagResponse
+// @Failure      404 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/tags [put]
+func (h *ProductHandler) SetTags(w http.ResponseWriter, r *http.Request) {
+	var req setTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	tags, err := h.productService.SetTags(r.Context(), h.scope(r), chi.URLParam(r, "id"), req.Tags)
+	if err != nil {
+		writeProductError(w, err, "could not set tags")
+		return
+	}
+
+	if tags == nil {
+		tags = []db.Tag{}
+	}
+	response.JSON(w, http.StatusOK, tags)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary      List trash
+// @Description  Deleted products that can still be restored, most recently deleted first
+// @Tags         products
+// @Produce      json
+// @Param        page      query int false "Page number (1-based)"
+// @Param        page_size query int false "Page size (max 100)"
+// @Success 200 {array}  ProductResponse
+// @Failure 400 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/trash [get]
+func (h *ProductHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.productService.Trash(r.Context(), h.scope(r), page)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch trash")
+		return
+	}
+
+	products := result.Products
+	if products == nil {
+		products = []db.Product{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, products, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Restore product
+// @Description  Take a deleted product back out of the trash
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success 200 {object} ProductResponse
+// @Failure 404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/restore [post]
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	product, err := h.productService.Restore(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeProductError(w, err, "could not restore product")
+		return
+	}
+
+	w.Header().Set("ETag", productETag(product))
+	response.JSON(w, http.StatusOK, product)
+}
+
+// @Summary      Purge product
+// @Description  Permanently delete a product that is in the trash. This can't be undone.
+// @Tags         products
+// @Param        id path string true "Product ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/trash/{id} [delete]
+func (h *ProductHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	if err := h.productService.Purge(r.Context(), h.scope(r), chi.URLParam(r, "id")); err != nil {
+		writeProductError(w, err, "could not purge product")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type variantRequest struct {
+	SKU string `json:"sku" validate:"required,max=64"`
+	// Options name what sets the variant apart, e.g. {"size": "M"}
+	Options map[string]string `json:"options" validate:"max=10,dive,keys,required,max=50,endkeys,required,max=100"`
+	// Price and Stock override the product's; leave them out to use its
+	Price *string `json:"price" validate:"omitempty,decimal=10 2"`
+	Stock *int32  `json:"stock" validate:"omitempty,min=0"`
+}
+
+func (req variantRequest) input() service.VariantInput {
+	input := service.VariantInput{
+		SKU:     req.SKU,
+		Options: req.Options,
+		Stock:   req.Stock,
+	}
+	if req.Price != nil {
+		// validated above
+		price := money.MustParse(*req.Price)
+		input.Price = &price
+	}
+	return input
+}
+
+// @Summary      List product variants
+// @Tags         products
+// @Produce      json
+// @Param        id path string true "Product ID"
+// @Success      200 {array}  ProductVariantResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants [get]
+func (h *ProductHandler) Variants(w http.ResponseWriter, r *http.Request) {
+	variants, err := h.productService.Variants(r.Context(), h.scope(r), chi.URLParam(r, "id"))
+	if err != nil {
+		writeVariantError(w, err, "could not fetch variants")
+		return
+	}
+
+	if variants == nil {
+		variants = []db.ProductVariant{}
+	}
+	response.JSON(w, http.StatusOK, variants)
+}
+
+// @Summary      Create product variant
+// @Description  Add a variant with its own SKU and options. The SKU must be unique among all your variants and the options among the product's. A price or stock left out is the product's.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id      path string         true "Product ID"
+// @Param        request body variantRequest true "Variant"
+// @Success      201 {object} ProductVariantResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	var req variantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	variant, err := h.productService.CreateVariant(r.Context(), h.scope(r), chi.URLParam(r, "id"), req.input())
+	if err != nil {
+		writeVariantError(w, err, "could not create variant")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, variant)
+}
+
+// @Summary      Get product variant
+// @Tags         products
+// @Produce      json
+// @Param        id         path string true "Product ID"
+// @Param        variant_id path string true "Variant ID"
+// @Success      200 {object} ProductVariantResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants/{variant_id} [get]
+func (h *ProductHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	variant, err := h.productService.GetVariant(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"))
+	if err != nil {
+		writeVariantError(w, err, "could not fetch variant")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, variant)
+}
+
+// @Summary      Replace product variant
+// @Description  Replace every field of the variant. Leaving out price or stock goes back to the product's. A change of stock is recorded in the variant's stock history; use the stock adjust endpoint to add or take stock without overwriting concurrent changes.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id         path string         true "Product ID"
+// @Param        variant_id path string         true "Variant ID"
+// @Param        request    body variantRequest true "Variant"
+// @Success      200 {object} ProductVariantResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants/{variant_id} [put]
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	var req variantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	variant, err := h.productService.UpdateVariant(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"), req.input())
+	if err != nil {
+		writeVariantError(w, err, "could not update variant")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, variant)
+}
+
+// @Summary      Delete product variant
+// @Tags         products
+// @Param        id         path string true "Product ID"
+// @Param        variant_id path string true "Variant ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/variants/{variant_id} [delete]
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	err := h.productService.DeleteVariant(r.Context(), h.scope(r), chi.URLParam(r, "id"), chi.URLParam(r, "variant_id"))
+	if err != nil {
+		writeVariantError(w, err, "could not delete variant")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func writeVariantError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrVariantNotFound):
+		response.Error(w, http.StatusNotFound, "variant not found")
+	case errors.Is(err, service.ErrDuplicateSKU):
+		response.Error(w, http.StatusConflict, "sku is already in use")
+	case errors.Is(err, service.ErrDuplicateVariant):
+		response.Error(w, http.StatusConflict, "product already has a variant with these options")
+	case errors.Is(err, service.ErrTooManyVariants):
+		response.Error(w, http.StatusConflict, "product has too many variants")
+	default:
+		writeProductError(w, err, fallback)
+	}
+}
@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+// couponMessages explains each reason a coupon doesn't apply
+var couponMessages = map[string]string{
+	service.CouponInactive:          "coupon is no longer active",
+	service.CouponNotStarted:        "coupon is not valid yet",
+	service.CouponExpired:           "coupon has expired",
+	service.CouponCurrency:          "coupon is for a different currency",
+	service.CouponMinOrder:          "order is below the coupon's minimum",
+	service.CouponUsedUp:            "coupon has been used up",
+	service.CouponUserLimit:         "you have already used this coupon",
+	service.CouponNothingToDiscount: "coupon does not apply to anything in the order",
+}
+
+type PromotionHandler struct {
+	promotionService *service.PromotionService
+}
+
+func NewPromotionHandler(promotionService *service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+type promotionRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+	// Code makes the promotion a coupon; leave it out to apply it to
+	// every order it fits
+	Code string `json:"code" validate:"omitempty,max=64"`
+	Kind string `json:"kind" validate:"required,oneof=percentage fixed"`
+	// Value is a percentage off, or an amount off in currency
+	Value string `json:"value" validate:"required,decimal=12 2"`
+	// Currency is required for fixed discounts and minimum orders
+	Currency       string     `json:"currency"          validate:"omitempty,currency"`
+	MinOrder       *string    `json:"min_order"         validate:"omitempty,decimal=12 2"`
+	MaxUses        *int32     `json:"max_uses"          validate:"omitempty,min=1"`
+	MaxUsesPerUser *int32     `json:"max_uses_per_user" validate:"omitempty,min=1"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Active         *bool      `json:"active"`
+	// ProductIDs limit the discount to these products; leave them out
+	// to discount the whole order
+	ProductIDs []string `json:"product_ids" validate:"max=1000,dive,uuid"`
+}
+
+func (req promotionRequest) input() service.PromotionInput {
+	input := service.PromotionInput{
+		Name: req.Name,
+		Code: req.Code,
+		Kind: req.Kind,
+		// validated above
+		Value:          money.MustParse(req.Value),
+		Currency:       req.Currency,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Active:         req.Active == nil || *req.Active,
+		ProductIDs:     req.ProductIDs,
+	}
+	if req.MinOrder != nil {
+		minOrder := money.MustParse(*req.MinOrder)
+		input.MinOrder = &minOrder
+	}
+	return input
+}
+
+type evaluateRequest struct {
+	Items      []orderItemRequest `json:"items"       validate:"required,min=1,max=100,dive"`
+	CouponCode string             `json:"coupon_code" validate:"omitempty,max=64"`
+}
+
+// @Summary      Evaluate basket
+// @Description  Price a basket at current prices with the promotions it would get at checkout, without using any up. A coupon that can't be used is reported in coupon_rejection rather than failing the request; an unknown one is a 422.
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Param        request body evaluateRequest true "Basket"
+// @Success      200 {object} EvaluationResponse
+// @Failure      400 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/promotions/evaluate [post]
+func (h *PromotionHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	var req evaluateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	items := make([]service.OrderItemInput, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, service.OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	eval, err := h.promotionService.Evaluate(r.Context(), userID, items, req.CouponCode)
+	if err != nil {
+		writeOrderError(w, err, "could not evaluate basket")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, eval)
+}
+
+// @Summary      List promotions
+// @Description  Every promotion, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        page      query int false "Page number (1-based)"
+// @Param        page_size query int false "Page size (max 100)"
+// @Success      200 {array}  PromotionResponse
+// @Failure      400 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/promotions [get]
+func (h *PromotionHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	result, err := h.promotionService.List(r.Context(), page)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch promotions")
+		return
+	}
+
+	promotions := result.Promotions
+	if promotions == nil {
+		promotions = []db.Promotion{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, promotions, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+// @Summary      Create promotion
+// @Description  Add a percentage or fixed-amount discount. With a code it's a coupon shoppers enter at checkout; without one it applies to every order it fits. Product IDs limit it to those products.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request body promotionRequest true "Promotion"
+// @Success      201 {object} PromotionResponse
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/promotions [post]
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req promotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	promotion, err := h.promotionService.Create(r.Context(), adminID, req.input())
+	if err != nil {
+		writePromotionError(w, err, "could not create promotion")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, promotion)
+}
+
+// @Summary      Get promotion
+// @Tags         admin
+// @Produce      json
+// @Param        id path string true "Promotion ID"
+// @Success      200 {object} PromotionResponse
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/promotions/{id} [get]
+func (h *PromotionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	promotion, err := h.promotionService.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writePromotionError(w, err, "could not fetch promotion")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, promotion)
+}
+
+// @Summary      Replace promotion
+// @Description  Replace every field of the promotion, its products included. Uses so far still count against the limits.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path string           true "Promotion ID"
+// @Param        request body promotionRequest true "Promotion"
+// @Success      200 {object} PromotionResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/promotions/{id} [put]
+func (h *PromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req promotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	promotion, err := h.promotionService.Update(r.Context(), chi.URLParam(r, "id"), req.input())
+	if err != nil {
+		writePromotionError(w, err, "could not update promotion")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, promotion)
+}
+
+// @Summary      Delete promotion
+// @Description  Orders that got the discount keep it
+// @Tags         admin
+// @Param        id path string true "Promotion ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/promotions/{id} [delete]
+func (h *PromotionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.promotionService.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writePromotionError(w, err, "could not delete promotion")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func writePromotionError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPromotionNotFound):
+		response.Error(w, http.StatusNotFound, "promotion not found")
+	case errors.Is(err, service.ErrDuplicateCouponCode):
+		response.Error(w, http.StatusConflict, "coupon code is already in use")
+	case errors.Is(err, service.ErrInvalidPromotionValue):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "value", Message: "must be a percentage up to 100, or an amount with no more decimal places than its currency"},
+		})
+	case errors.Is(err, service.ErrInvalidPrice):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "min_order", Message: "must not have more decimal places than its currency"},
+		})
+	case errors.Is(err, service.ErrPromotionCurrency):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "currency", Message: "is required for fixed discounts and minimum orders"},
+		})
+	case errors.Is(err, service.ErrPromotionWindow):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "ends_at", Message: "must be after starts_at"},
+		})
+	case errors.Is(err, service.ErrInvalidPromotionProduct):
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "product_ids", Message: "contains a product that does not exist"},
+		})
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -1,5 +1,7 @@
 package handler
 
+import "github.com/falasefemi2/goreact-boilerplate/internal/service"
+
 // ProductResponse is used for API documentation
 // It mirrors db.Product but with plain Go types swag understands
 type ProductResponse struct {
@@ -8,14 +10,264 @@ type ProductResponse struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
 	Price       string `json:"price"`
+	Currency    string `json:"currency"`
 	Stock       int32  `json:"stock"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
+	Version     int32  `json:"version"`
+	DeletedAt   string `json:"deleted_at,omitempty"`
+	// RatingCount counts approved reviews; RatingAverage is null
+	// until there is one
+	RatingCount   int32  `json:"rating_count"`
+	RatingAverage string `json:"rating_average"`
+	// IsPublished puts the product in the public catalog
+	IsPublished bool `json:"is_published"`
 }
 
-type UserResponse struct {
+// CatalogProductResponse mirrors db.CatalogProduct for the docs: the
+// public view of a published product, without its owner or exact stock
+type CatalogProductResponse struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Price         string `json:"price"`
+	Currency      string `json:"currency"`
+	InStock       bool   `json:"in_stock"`
+	RatingCount   int32  `json:"rating_count"`
+	RatingAverage string `json:"rating_average"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// SearchResultResponse is a product with its relevance and a snippet.
+// The headline is HTML: the product's text escaped, with matches
+// wrapped in <mark> tags.
+type SearchResultResponse struct {
+	ProductResponse
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+// ProductImportResponse mirrors db.ProductImport for the docs
+type ProductImportResponse struct {
+	ID           string                   `json:"id"`
+	UserID       string                   `json:"user_id"`
+	Mode         string                   `json:"mode"`
+	Status       string                   `json:"status"`
+	TotalRows    int32                    `json:"total_rows"`
+	InsertedRows int32                    `json:"inserted_rows"`
+	FailedRows   int32                    `json:"failed_rows"`
+	Errors       []service.ImportRowError `json:"errors"`
+	CreatedAt    string                   `json:"created_at"`
+	FinishedAt   string                   `json:"finished_at,omitempty"`
+}
+
+// StockMovementResponse mirrors db.StockMovement for the docs. UserID
+// is who made the change; it's null once that account is deleted.
+// VariantID is set when the movement is of a variant's own stock.
+type StockMovementResponse struct {
+	ID         string `json:"id"`
+	ProductID  string `json:"product_id"`
+	UserID     string `json:"user_id"`
+	Reason     string `json:"reason"`
+	Delta      int32  `json:"delta"`
+	StockAfter int32  `json:"stock_after"`
+	Note       string `json:"note,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	VariantID  string `json:"variant_id"`
+}
+
+// ProductVariantResponse mirrors db.ProductVariant for the docs. Price
+// and stock are null when the variant takes the product's.
+type ProductVariantResponse struct {
+	ID        string            `json:"id"`
+	ProductID string            `json:"product_id"`
+	UserID    string            `json:"user_id"`
+	SKU       string            `json:"sku"`
+	Options   map[string]string `json:"options"`
+	Price     *string           `json:"price"`
+	Stock     *int32            `json:"stock"`
+	CreatedAt string            `json:"created_at"`
+	UpdatedAt string            `json:"updated_at"`
+}
+
+// PriceHistoryResponse mirrors db.PriceHistory for the docs. Reason is
+// initial, update or scheduled; ScheduledChangeID is set for the last.
+type PriceHistoryResponse struct {
+	ID                string `json:"id"`
+	ProductID         string `json:"product_id"`
+	UserID            string `json:"user_id"`
+	Price             string `json:"price"`
+	Currency          string `json:"currency"`
+	Reason            string `json:"reason"`
+	ScheduledChangeID string `json:"scheduled_change_id"`
+	CreatedAt         string `json:"created_at"`
+}
+
+// ScheduledPriceChangeResponse mirrors db.ScheduledPriceChange for the
+// docs. Status is pending, applied or skipped; ProcessedAt is null
+// while it's pending.
+type ScheduledPriceChangeResponse struct {
+	ID          string `json:"id"`
+	ProductID   string `json:"product_id"`
+	UserID      string `json:"user_id"`
+	Price       string `json:"price"`
+	Currency    string `json:"currency"`
+	EffectiveAt string `json:"effective_at"`
+	Status      string `json:"status"`
+	ProcessedAt string `json:"processed_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// OrderResponse mirrors service.Order for the docs. The status
+// timestamps are null until the order reaches that status. Total is
+// Subtotal less Discount.
+type OrderResponse struct {
+	ID          string              `json:"id"`
+	UserID      string              `json:"user_id"`
+	Status      string              `json:"status"`
+	Total       string              `json:"total"`
+	Currency    string              `json:"currency"`
+	CreatedAt   string              `json:"created_at"`
+	UpdatedAt   string              `json:"updated_at"`
+	PaidAt      string              `json:"paid_at"`
+	ShippedAt   string              `json:"shipped_at"`
+	DeliveredAt string              `json:"delivered_at"`
+	CancelledAt string              `json:"cancelled_at"`
+	Subtotal    string              `json:"subtotal"`
+	Discount    string              `json:"discount"`
+	Items       []OrderItemResponse `json:"items"`
+}
+
+// OrderItemResponse is an order line. Name and price are as they were
+// at checkout; ProductID is null once the product is purged.
+type OrderItemResponse struct {
+	ID          string `json:"id"`
+	OrderID     string `json:"order_id"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	UnitPrice   string `json:"unit_price"`
+	Quantity    int32  `json:"quantity"`
+}
+
+// MoneyResponse mirrors money.Money: the amount is a decimal string
+// at the currency's minor units
+type MoneyResponse struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// CartResponse mirrors service.Cart for the docs
+type CartResponse struct {
+	ID       string             `json:"id"`
+	Items    []CartItemResponse `json:"items"`
+	Subtotal MoneyResponse      `json:"subtotal"`
+}
+
+// CartItemResponse is a cart line checked against its product.
+// UnitPrice is today's price and AddedPrice the one it was added at.
+// Shoppers only learn whether it's in stock, not how much there is.
+type CartItemResponse struct {
+	ProductID  string   `json:"product_id"`
+	Name       string   `json:"name"`
+	Quantity   int32    `json:"quantity"`
+	UnitPrice  string   `json:"unit_price"`
+	AddedPrice string   `json:"added_price"`
+	LineTotal  string   `json:"line_total"`
+	Currency   string   `json:"currency"`
+	InStock    bool     `json:"in_stock"`
+	Issues     []string `json:"issues"`
+}
+
+// CategoryResponse mirrors db.Category for the docs. ParentID is null
+// for top-level categories.
+type CategoryResponse struct {
 	ID        string `json:"id"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
+	UserID    string `json:"user_id"`
+	ParentID  string `json:"parent_id"`
+	Name      string `json:"name"`
 	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type TagResponse struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+type UserResponse struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	DisabledAt    string `json:"disabled_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type AuditLogResponse struct {
+	ID           string            `json:"id"`
+	AdminID      string            `json:"admin_id"`
+	TargetUserID string            `json:"target_user_id"`
+	Action       string            `json:"action"`
+	Details      map[string]string `json:"details"`
+	CreatedAt    string            `json:"created_at"`
+}
+
+// PromotionResponse mirrors service.Promotion for the docs. Code is
+// null for promotions that apply by themselves; the limits, minimum
+// and window are null when there are none. ProductIDs is empty when it
+// applies to the whole order.
+type PromotionResponse struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Code           string   `json:"code"`
+	Kind           string   `json:"kind"`
+	Value          string   `json:"value"`
+	Currency       string   `json:"currency"`
+	MinOrder       string   `json:"min_order"`
+	MaxUses        int32    `json:"max_uses"`
+	MaxUsesPerUser int32    `json:"max_uses_per_user"`
+	StartsAt       string   `json:"starts_at"`
+	EndsAt         string   `json:"ends_at"`
+	Active         bool     `json:"active"`
+	CreatedBy      string   `json:"created_by"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+	ProductIDs     []string `json:"product_ids"`
+}
+
+// EvaluationResponse mirrors service.Evaluation for the docs.
+// CouponRejection is left out when no coupon was given or it applied.
+type EvaluationResponse struct {
+	Subtotal        MoneyResponse             `json:"subtotal"`
+	Discounts       []AppliedDiscountResponse `json:"discounts"`
+	Discount        MoneyResponse             `json:"discount"`
+	Total           MoneyResponse             `json:"total"`
+	CouponRejection string                    `json:"coupon_rejection,omitempty"`
+}
+
+type AppliedDiscountResponse struct {
+	PromotionID string        `json:"promotion_id"`
+	Name        string        `json:"name"`
+	Code        string        `json:"code"`
+	Amount      MoneyResponse `json:"amount"`
+}
+
+// ReviewResponse mirrors db.Review for the docs. Status is pending,
+// approved or rejected; the moderation fields are null until a
+// moderator has looked at it.
+type ReviewResponse struct {
+	ID          string `json:"id"`
+	ProductID   string `json:"product_id"`
+	UserID      string `json:"user_id"`
+	Rating      int16  `json:"rating"`
+	Body        string `json:"body"`
+	Status      string `json:"status"`
+	ModeratedBy string `json:"moderated_by"`
+	ModeratedAt string `json:"moderated_at"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
 }
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+	allUsers      bool
+}
+
+func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// NewAdminReviewHandler can delete anyone's review. Mount it only
+// behind RequirePermission(rbac.PermModerateReviews).
+func NewAdminReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService, allUsers: true}
+}
+
+func (h *ReviewHandler) scope(r *http.Request) service.Scope {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	if h.allUsers {
+		return service.AdminScope(userID)
+	}
+	return service.OwnerScope(userID)
+}
+
+type reviewRequest struct {
+	Rating int16  `json:"rating" validate:"required,min=1,max=5"`
+	Body   string `json:"body"   validate:"max=5000"`
+}
+
+type reviewStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending approved rejected"`
+}
+
+// @Summary      List product reviews
+// @Description  Approved reviews of a published product or your own, newest first, plus your review whatever its status
+// @Tags         reviews
+// @Produce      json
+// @Param        id        path  string true  "Product ID"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success      200 {array}  ReviewResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/reviews [get]
+func (h *ReviewHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	result, err := h.reviewService.List(r.Context(), userID, chi.URLParam(r, "id"), page)
+	if err != nil {
+		writeReviewError(w, err, "could not fetch reviews")
+		return
+	}
+
+	writeReviewPage(w, result, page)
+}
+
+// @Summary      Review product
+// @Description  Rate someone else's published product from 1 to 5 stars. One review per product; it's shown to others and counts toward the product's rating once a moderator approves it.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path string        true "Product ID"
+// @Param        request body reviewRequest true "Review"
+// @Success      201 {object} ReviewResponse
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/products/{id}/reviews [post]
+func (h *ReviewHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	review, err := h.reviewService.Create(r.Context(), userID, chi.URLParam(r, "id"), service.ReviewInput{
+		Rating: req.Rating,
+		Body:   req.Body,
+	})
+	if err != nil {
+		writeReviewError(w, err, "could not create review")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, review)
+}
+
+// @Summary      Edit review
+// @Description  Change your review's rating and text. It goes back to moderation and stops counting toward the rating until approved again.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path string        true "Review ID"
+// @Param        request body reviewRequest true "Review"
+// @Success      200 {object} ReviewResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/reviews/{id} [put]
+func (h *ReviewHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	review, err := h.reviewService.Update(r.Context(), userID, chi.URLParam(r, "id"), service.ReviewInput{
+		Rating: req.Rating,
+		Body:   req.Body,
+	})
+	if err != nil {
+		writeReviewError(w, err, "could not update review")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, review)
+}
+
+// @Summary      Delete review
+// @Description  Delete your review. Moderators can delete anyone's.
+// @Tags         reviews
+// @Param        id path string true "Review ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/reviews/{id} [delete]
+func (h *ReviewHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.reviewService.Delete(r.Context(), h.scope(r), chi.URLParam(r, "id")); err != nil {
+		writeReviewError(w, err, "could not delete review")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary      Moderation queue
+// @Description  Reviews oldest first, for moderation. Filter by status to see just the ones waiting.
+// @Tags         admin
+// @Produce      json
+// @Param        status    query string false "pending, approved or rejected"
+// @Param        page      query int    false "Page number (1-based)"
+// @Param        page_size query int    false "Page size (max 100)"
+// @Success      200 {array}  ReviewResponse
+// @Failure      400 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/reviews [get]
+func (h *ReviewHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(r)
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !service.ValidReviewStatus(status) {
+		response.ValidationError(w, []appvalidator.ValidationError{
+			{Field: "status", Message: "must be one of pending, approved, rejected"},
+		})
+		return
+	}
+
+	result, err := h.reviewService.Queue(r.Context(), status, page)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch reviews")
+		return
+	}
+
+	writeReviewPage(w, result, page)
+}
+
+// @Summary      Moderate review
+// @Description  Approve or reject a review, or put it back to pending. The product's rating moves as a review enters or leaves approved.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path string              true "Review ID"
+// @Param        request body reviewStatusRequest true "New status"
+// @Success      200 {object} ReviewResponse
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/admin/reviews/{id}/status [post]
+func (h *ReviewHandler) Moderate(w http.ResponseWriter, r *http.Request) {
+	var req reviewStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return
+	}
+
+	moderatorID := r.Context().Value(middleware.UserIDKey).(string)
+	review, err := h.reviewService.Moderate(r.Context(), moderatorID, chi.URLParam(r, "id"), req.Status)
+	if err != nil {
+		writeReviewError(w, err, "could not moderate review")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, review)
+}
+
+func writeReviewPage(w http.ResponseWriter, result service.ReviewPage, page service.Page) {
+	reviews := result.Reviews
+	if reviews == nil {
+		reviews = []db.Review{}
+	}
+
+	response.JSONWithMeta(w, http.StatusOK, reviews, pageMeta{
+		Page:     page.Number,
+		PageSize: page.Size,
+		Total:    result.Total,
+	})
+}
+
+func writeReviewError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrReviewNotFound):
+		response.Error(w, http.StatusNotFound, "review not found")
+	case errors.Is(err, service.ErrProductNotFound):
+		response.Error(w, http.StatusNotFound, "product not found")
+	case errors.Is(err, service.ErrOwnProduct):
+		response.Error(w, http.StatusForbidden, "you cannot review your own product")
+	case errors.Is(err, service.ErrAlreadyReviewed):
+		response.Error(w, http.StatusConflict, "you have already reviewed this product")
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
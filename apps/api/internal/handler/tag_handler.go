@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/response"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	appvalidator "github.com/falasefemi2/goreact-boilerplate/internal/validator"
+	"github.com/go-chi/chi/v5"
+)
+
+type TagHandler struct {
+	tagService *service.TagService
+}
+
+func NewTagHandler(tagService *service.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+type tagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// @Summary      List tags
+// @Tags         tags
+// @Produce      json
+// @Success      200 {array}  TagResponse
+// @Security     CookieAuth
+// @Router       /api/v1/tags [get]
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	tags, err := h.tagService.List(r.Context(), userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "could not fetch tags")
+		return
+	}
+
+	if tags == nil {
+		tags = []db.Tag{}
+	}
+	response.JSON(w, http.StatusOK, tags)
+}
+
+// @Summary      Create tag
+// @Description  Tags are also created automatically when a product is tagged with a new name
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        request body tagRequest true "Tag"
+// @Success      201 {object} TagResponse
+// @Failure      409 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/tags [post]
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	req, ok := decodeTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagService.Create(r.Context(), userID, req.Name)
+	if err != nil {
+		writeTagError(w, err, "could not create tag")
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, tag)
+}
+
+// @Summary      Rename tag
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id      path string     true "Tag ID"
+// @Param        request body tagRequest true "Tag"
+// @Success      200 {object} TagResponse
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/tags/{id} [put]
+func (h *TagHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	req, ok := decodeTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagService.Rename(r.Context(), userID, chi.URLParam(r, "id"), req.Name)
+	if err != nil {
+		writeTagError(w, err, "could not rename tag")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, tag)
+}
+
+// @Summary      Delete tag
+// @Description  Removes the tag from every product carrying it
+// @Tags         tags
+// @Param        id path string true "Tag ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Security     CookieAuth
+// @Router       /api/v1/tags/{id} [delete]
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	if err := h.tagService.Delete(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeTagError(w, err, "could not delete tag")
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func decodeTagRequest(w http.ResponseWriter, r *http.Request) (tagRequest, bool) {
+	var req tagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid request body")
+		return req, false
+	}
+
+	if errs := appvalidator.Validate(req); errs != nil {
+		response.ValidationError(w, errs)
+		return req, false
+	}
+
+	return req, true
+}
+
+func writeTagError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrTagNotFound):
+		response.Error(w, http.StatusNotFound, "tag not found")
+	case errors.Is(err, service.ErrTagExists):
+		response.Error(w, http.StatusConflict, "a tag with this name already exists")
+	default:
+		response.Error(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -2,21 +2,53 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/falasefemi2/goreact-boilerplate/internal/rbac"
+	"github.com/falasefemi2/goreact-boilerplate/internal/service"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey        contextKey = "userID"
+	EmailVerifiedKey contextKey = "emailVerified"
+	RoleKey          contextKey = "role"
+)
+
+// AccessCookieName is the httpOnly cookie the access JWT travels in
+const AccessCookieName = "auth_token"
+
+// RevocationChecker reports whether an otherwise valid token
+// has been revoked server-side
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti, userID string, version int64) (bool, error)
+}
+
+func RequireAuth(jwtSecret string, revocations RevocationChecker) func(http.Handler) http.Handler {
+	return authenticate(jwtSecret, revocations, false)
+}
 
-func RequireAuth(jwtSecret string) func(http.Handler) http.Handler {
+// OptionalAuth lets requests without an access token through
+// anonymously, with no UserIDKey in the context. A token that is
+// present but invalid or expired is still rejected, so a logged-in
+// client refreshes instead of silently turning anonymous.
+func OptionalAuth(jwtSecret string, revocations RevocationChecker) func(http.Handler) http.Handler {
+	return authenticate(jwtSecret, revocations, true)
+}
+
+func authenticate(jwtSecret string, revocations RevocationChecker, optional bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Read token from httpOnly cookie
-			cookie, err := r.Cookie("auth_token")
+			cookie, err := r.Cookie(AccessCookieName)
 			if err != nil {
+				if optional {
+					next.ServeHTTP(w, r)
+					return
+				}
 				http.Error(w, `{"error":"missing token"}`, http.StatusUnauthorized)
 				return
 			}
@@ -28,7 +60,14 @@ func RequireAuth(jwtSecret string) func(http.Handler) http.Handler {
 					return nil, jwt.ErrSignatureInvalid
 				}
 				return []byte(jwtSecret), nil
-			})
+			}, jwt.WithExpirationRequired())
+
+			// access tokens are short-lived — tell the client to
+			// hit /auth/refresh instead of sending it back to login
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				http.Error(w, `{"error":"token expired"}`, http.StatusUnauthorized)
+				return
+			}
 
 			if err != nil || !token.Valid {
 				http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
@@ -36,10 +75,85 @@ func RequireAuth(jwtSecret string) func(http.Handler) http.Handler {
 			}
 
 			claims := token.Claims.(jwt.MapClaims)
-			userID := claims["sub"].(string)
+			userID, err := claims.GetSubject()
+			if err != nil || userID == "" {
+				http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+				return
+			}
+
+			// only access tokens may authenticate requests
+			if typ, _ := claims["typ"].(string); typ != service.TokenTypeAccess {
+				http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+				return
+			}
+
+			jti, _ := claims["jti"].(string)
+			version, _ := claims["ver"].(float64)
+
+			revoked, err := revocations.IsRevoked(r.Context(), jti, userID, int64(version))
+			if err != nil {
+				http.Error(w, `{"error":"something went wrong"}`, http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				http.Error(w, `{"error":"token revoked"}`, http.StatusUnauthorized)
+				return
+			}
+
+			emailVerified, _ := claims["evf"].(bool)
+			role, _ := claims["role"].(string)
 
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, EmailVerifiedKey, emailVerified)
+			ctx = context.WithValue(ctx, RoleKey, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// RequireVerifiedEmail rejects accounts that haven't confirmed their
+// email address. It must run after RequireAuth.
+func RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if verified, _ := r.Context().Value(EmailVerifiedKey).(bool); !verified {
+			http.Error(w, `{"error":"email not verified"}`, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole only lets through users holding one of the given roles.
+// It must run after RequireAuth.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value(RoleKey).(string)
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		})
+	}
+}
+
+// RequirePermission only lets through users whose role grants perm.
+// It must run after RequireAuth.
+func RequirePermission(perm rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value(RoleKey).(string)
+			if !rbac.HasPermission(role, perm) {
+				http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
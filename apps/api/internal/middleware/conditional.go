@@ -0,0 +1,17 @@
+package middleware
+
+import "net/http"
+
+// RequireIfMatch rejects writes that don't say which version of the
+// resource they expect to overwrite, so a lost update can't happen
+// just because a client forgot the header
+func RequireIfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") == "" {
+			http.Error(w, `{"error":"If-Match header is required"}`, http.StatusPreconditionRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
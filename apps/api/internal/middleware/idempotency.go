@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response served from storage
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	// bodies bigger than this aren't worth fingerprinting; no endpoint
+	// that takes a key accepts one
+	maxIdempotentBody = 1 << 20
+)
+
+// Idempotency makes POST and PATCH safe to retry. The first request
+// with a given Idempotency-Key runs normally and its response is kept
+// in Postgres; later requests from the same user with the same key get
+// that response back without running the handler again.
+type Idempotency struct {
+	queries db.Querier
+	ttl     time.Duration
+}
+
+func NewIdempotency(queries db.Querier, ttl time.Duration) *Idempotency {
+	return &Idempotency{queries: queries, ttl: ttl}
+}
+
+// Handle must run after RequireAuth: keys are scoped per user.
+// Requests without the header, or with other methods, pass through.
+func (i *Idempotency) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			http.Error(w, `{"error":"Idempotency-Key is too long"}`, http.StatusBadRequest)
+			return
+		}
+
+		rawUserID, _ := r.Context().Value(UserIDKey).(string)
+		userID, err := uuid.Parse(rawUserID)
+		if err != nil {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			http.Error(w, `{"error":"request body too large"}`, http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(r, body)
+
+		claimed, err := i.queries.ClaimIdempotencyKey(r.Context(), db.ClaimIdempotencyKeyParams{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   time.Now().Add(i.ttl),
+		})
+		if err != nil {
+			http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		if claimed == 0 {
+			i.replay(w, r, userID, key, fingerprint)
+			return
+		}
+
+		i.record(w, r, next, userID, key)
+	})
+}
+
+// record runs the handler, teeing its response into storage. Server
+// errors and panics release the key instead, so the client can retry.
+func (i *Idempotency) record(w http.ResponseWriter, r *http.Request, next http.Handler, userID uuid.UUID, key string) {
+	// saving must happen even if the client has gone away
+	ctx := context.WithoutCancel(r.Context())
+	params := db.DeleteIdempotencyKeyParams{UserID: userID, Key: key}
+
+	saved := false
+	defer func() {
+		if saved {
+			return
+		}
+		if err := i.queries.DeleteIdempotencyKey(ctx, params); err != nil {
+			slog.Error("failed to release idempotency key", "error", err)
+		}
+	}()
+
+	// only headers the handler sets belong to the stored response;
+	// CORS and friends are added fresh on every request anyway
+	before := w.Header().Clone()
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	if rec.status >= http.StatusInternalServerError {
+		return
+	}
+
+	headers, err := json.Marshal(addedHeaders(before, w.Header()))
+	if err != nil {
+		slog.Error("failed to encode idempotent response headers", "error", err)
+		return
+	}
+
+	if err := i.queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		UserID:          userID,
+		Key:             key,
+		StatusCode:      sql.NullInt32{Int32: int32(rec.status), Valid: true},
+		ResponseHeaders: headers,
+		ResponseBody:    rec.body.Bytes(),
+	}); err != nil {
+		slog.Error("failed to store idempotent response", "error", err)
+		return
+	}
+	saved = true
+}
+
+// replay answers a request whose key has been seen before
+func (i *Idempotency) replay(w http.ResponseWriter, r *http.Request, userID uuid.UUID, key, fingerprint string) {
+	stored, err := i.queries.GetIdempotencyKey(r.Context(), db.GetIdempotencyKeyParams{
+		UserID: userID,
+		Key:    key,
+	})
+	if err != nil {
+		// released between our claim and this read; asking the
+		// client to retry is simpler than racing for it again
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, `{"error":"a request with this Idempotency-Key is in progress"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		http.Error(w,
+			`{"error":"Idempotency-Key was already used for a different request"}`,
+			http.StatusUnprocessableEntity,
+		)
+		return
+	}
+	if !stored.StatusCode.Valid {
+		http.Error(w, `{"error":"a request with this Idempotency-Key is in progress"}`, http.StatusConflict)
+		return
+	}
+
+	var headers http.Header
+	if err := json.Unmarshal(stored.ResponseHeaders, &headers); err != nil {
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	for name, values := range headers {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(int(stored.StatusCode.Int32))
+	w.Write(stored.ResponseBody)
+}
+
+// Cleanup is a Job that deletes expired keys every hour
+func (i *Idempotency) Cleanup(ctx context.Context) {
+	background.Every(ctx, time.Hour, func(ctx context.Context) {
+		if err := i.queries.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+			slog.Error("failed to delete expired idempotency keys", "error", err)
+		}
+	})
+}
+
+// requestFingerprint identifies what a request asked for, so a key
+// can't be reused for a different endpoint or payload
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func addedHeaders(before, after http.Header) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if _, ok := before[name]; !ok {
+			added[name] = values
+		}
+	}
+	return added
+}
+
+// responseRecorder passes the response through while keeping a copy
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -33,17 +34,17 @@ func NewRateLimiter(r rate.Limit, burst int) *RateLimiter {
 	return rl
 }
 
-func (rl *RateLimiter) getClient(ip string) *rate.Limiter {
+func (rl *RateLimiter) getClient(key string) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	if c, exists := rl.clients[ip]; exists {
+	if c, exists := rl.clients[key]; exists {
 		c.lastSeen = time.Now()
 		return c.limiter
 	}
 
 	limiter := rate.NewLimiter(rl.rate, rl.burst)
-	rl.clients[ip] = &client{
+	rl.clients[key] = &client{
 		limiter:  limiter,
 		lastSeen: time.Now(),
 	}
@@ -55,20 +56,38 @@ func (rl *RateLimiter) cleanup() {
 	for {
 		time.Sleep(time.Minute)
 		rl.mu.Lock()
-		for ip, c := range rl.clients {
+		for key, c := range rl.clients {
 			if time.Since(c.lastSeen) > 3*time.Minute {
-				delete(rl.clients, ip)
+				delete(rl.clients, key)
 			}
 		}
 		rl.mu.Unlock()
 	}
 }
 
+// Limit keys the limiter on the client IP. RemoteAddr carries the
+// port too, which changes with every connection, so it's dropped.
 func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
+	return rl.limitBy(func(r *http.Request) string {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			return host
+		}
+		return r.RemoteAddr
+	}, next)
+}
 
-		if !rl.getClient(ip).Allow() {
+// LimitByUser keys the limiter on the authenticated user instead of
+// the client IP. It must run after RequireAuth.
+func (rl *RateLimiter) LimitByUser(next http.Handler) http.Handler {
+	return rl.limitBy(func(r *http.Request) string {
+		userID, _ := r.Context().Value(UserIDKey).(string)
+		return userID
+	}, next)
+}
+
+func (rl *RateLimiter) limitBy(key func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rl.getClient(key(r)).Allow() {
 			http.Error(w,
 				`{"error":"too many requests, slow down"}`,
 				http.StatusTooManyRequests,
@@ -0,0 +1,298 @@
+// Package money does exact decimal arithmetic for prices. Amounts never
+// pass through float64: they're parsed from and rendered to the same
+// base-10 text Postgres uses for NUMERIC.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// maxDecimalLen caps what Parse reads, so a huge number can't make
+// every sum and comparison on it slow. It's far more than any price
+// or total Postgres sends us.
+const maxDecimalLen = 100
+
+// Decimal is an exact base-10 number: an integer coefficient scaled
+// by 10^-scale, so 12.50 is 1250 with scale 2. The zero value is 0.
+//
+// A Decimal is immutable. The coefficient is never modified once set,
+// so copies can share it safely.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+var ten = big.NewInt(10)
+
+// NewDecimal returns coef × 10^-scale
+func NewDecimal(coef int64, scale int32) Decimal {
+	if scale < 0 {
+		panic("money: negative scale")
+	}
+	return Decimal{coef: big.NewInt(coef), scale: scale}
+}
+
+// Parse reads a plain decimal such as "12", "-0.5" or "1234.50", with
+// digits on both sides of any point. Plus signs, exponents, NaN,
+// infinities, stray characters and input over maxDecimalLen are
+// rejected.
+func Parse(s string) (Decimal, error) {
+	if len(s) > maxDecimalLen {
+		return Decimal{}, fmt.Errorf("%w: longer than %d characters", ErrInvalidDecimal, maxDecimalLen)
+	}
+
+	digits, neg := strings.CutPrefix(s, "-")
+	intPart, fracPart, hasPoint := strings.Cut(digits, ".")
+	if intPart == "" || hasPoint && fracPart == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+			}
+		}
+	}
+
+	coef, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if neg {
+		coef.Neg(coef)
+	}
+	return Decimal{coef: coef, scale: int32(len(fracPart))}, nil
+}
+
+// MustParse is Parse for constants; it panics on bad input
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Scale is the number of digits after the decimal point, as written.
+// 1.50 has scale 2 even though it equals 1.5.
+func (d Decimal) Scale() int32 { return d.scale }
+
+// Precision is the number of significant digits, ignoring trailing
+// zeros after the point. It's the p in Postgres' NUMERIC(p, s).
+func (d Decimal) Precision() int {
+	t := d.trim()
+	n := len(new(big.Int).Abs(t.int()).String())
+	// 0.05 is 5 with scale 2: its two digits are all after the point
+	return max(n, int(t.scale))
+}
+
+// trim drops trailing zeros after the point
+func (d Decimal) trim() Decimal {
+	coef := new(big.Int).Set(d.int())
+	scale := d.scale
+	mod := new(big.Int)
+	for scale > 0 {
+		q, r := new(big.Int).QuoRem(coef, ten, mod)
+		if r.Sign() != 0 {
+			break
+		}
+		coef = q
+		scale--
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// MinScale is the smallest scale d can be written at without losing
+// digits: 1.50 has min scale 1
+func (d Decimal) MinScale() int32 { return d.trim().scale }
+
+// rescale writes d with a larger scale
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.int()
+	}
+	factor := new(big.Int).Exp(ten, big.NewInt(int64(scale-d.scale)), nil)
+	return factor.Mul(factor, d.int())
+}
+
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	scale := max(a.scale, b.scale)
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	x, y, scale := align(d, o)
+	return Decimal{coef: new(big.Int).Add(x, y), scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	x, y, scale := align(d, o)
+	return Decimal{coef: new(big.Int).Sub(x, y), scale: scale}
+}
+
+// Mul is exact: the scales add up
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+func (d Decimal) MulInt(n int64) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), big.NewInt(n)), scale: d.scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Round rounds to scale digits after the point, halves away from zero.
+// Rounding to a larger scale just pads with zeros.
+func (d Decimal) Round(scale int32) Decimal {
+	if scale >= d.scale {
+		return Decimal{coef: d.rescale(scale), scale: scale}
+	}
+
+	factor := new(big.Int).Exp(ten, big.NewInt(int64(d.scale-scale)), nil)
+	q, r := new(big.Int).QuoRem(d.int(), factor, new(big.Int))
+	// |r| * 2 >= factor means we're at or past the half
+	if r.Abs(r).Lsh(r, 1).Cmp(factor) >= 0 {
+		if d.int().Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{coef: q, scale: scale}
+}
+
+// Cmp returns -1, 0 or +1 as d is less than, equal to or greater than
+// o. Scale doesn't matter: 1.5 and 1.50 are equal.
+func (d Decimal) Cmp(o Decimal) int {
+	x, y, _ := align(d, o)
+	return x.Cmp(y)
+}
+
+func (d Decimal) Equal(o Decimal) bool { return d.Cmp(o) == 0 }
+
+func (d Decimal) Sign() int { return d.int().Sign() }
+
+func (d Decimal) IsZero() bool { return d.Sign() == 0 }
+
+// String writes every digit of the scale: 1250 with scale 2 is "12.50"
+func (d Decimal) String() string {
+	abs := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + abs
+	}
+
+	if pad := int(d.scale) + 1 - len(abs); pad > 0 {
+		abs = strings.Repeat("0", pad) + abs
+	}
+	point := len(abs) - int(d.scale)
+	return sign + abs[:point] + "." + abs[point:]
+}
+
+// MarshalJSON writes a JSON string, so no client reads it into a float
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column
+func (d *Decimal) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		*d = NewDecimal(v, 0)
+		return nil
+	case nil:
+		return errors.New("money: cannot scan NULL into Decimal")
+	default:
+		return fmt.Errorf("money: cannot scan %T into Decimal", src)
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value passes the decimal to Postgres as text, which it parses exactly
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// NullDecimal is a Decimal that may be NULL
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool
+}
+
+func (n *NullDecimal) Scan(src any) error {
+	if src == nil {
+		*n = NullDecimal{}
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.Scan(src)
+}
+
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+func (n NullDecimal) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.Decimal.MarshalJSON()
+}
+
+func (n *NullDecimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = NullDecimal{}
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.UnmarshalJSON(data)
+}
@@ -0,0 +1,182 @@
+package money
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"12", "12"},
+		{"-0.5", "-0.5"},
+		{"1234.50", "1234.50"},
+		{"0.05", "0.05"},
+		{"007", "7"},
+		{"-0", "0"},
+		{strings.Repeat("9", maxDecimalLen), strings.Repeat("9", maxDecimalLen)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"-",
+		".",
+		".5",
+		"-.5",
+		"5.",
+		"+1",
+		"1e3",
+		"1E3",
+		"NaN",
+		"Infinity",
+		"1,000.00",
+		"1.2.3",
+		" 1",
+		"1 ",
+		"--1",
+		"0x10",
+		strings.Repeat("9", maxDecimalLen+1),
+		"0." + strings.Repeat("0", maxDecimalLen),
+	}
+
+	for _, in := range tests {
+		t.Run(in, func(t *testing.T) {
+			if d, err := Parse(in); !errors.Is(err, ErrInvalidDecimal) {
+				t.Errorf("got %s, %v, want ErrInvalidDecimal", d, err)
+			}
+		})
+	}
+}
+
+// Round goes half away from zero, the same as Postgres' round()
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in    string
+		scale int32
+		want  string
+	}{
+		{"1.004", 2, "1.00"},
+		{"1.005", 2, "1.01"},
+		{"1.015", 2, "1.02"},
+		{"-1.004", 2, "-1.00"},
+		{"-1.005", 2, "-1.01"},
+		{"-1.015", 2, "-1.02"},
+		{"0.5", 0, "1"},
+		{"-0.5", 0, "-1"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"2.49", 0, "2"},
+		{"-2.49", 0, "-2"},
+		{"-0.004", 2, "0.00"},
+		{"-0.005", 2, "-0.01"},
+		{"99.995", 2, "100.00"},
+		{"12.345", 1, "12.3"},
+		{"1.2", 3, "1.200"},
+		{"7", 2, "7.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := MustParse(tt.in).Round(tt.scale)
+			if got.String() != tt.want {
+				t.Errorf("Round(%d) = %s, want %s", tt.scale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinScaleAndPrecision(t *testing.T) {
+	tests := []struct {
+		in        string
+		minScale  int32
+		precision int
+	}{
+		{"0.05", 2, 2},
+		{"1.50", 1, 2},
+		{"100000000.00", 0, 9},
+		{"0", 0, 1},
+		{"0.00", 0, 1},
+		{"-12.340", 2, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d := MustParse(tt.in)
+			if got := d.MinScale(); got != tt.minScale {
+				t.Errorf("MinScale() = %d, want %d", got, tt.minScale)
+			}
+			if got := d.Precision(); got != tt.precision {
+				t.Errorf("Precision() = %d, want %d", got, tt.precision)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want string
+	}{
+		{"12.50", "12.50"},
+		{[]byte("-0.05"), "-0.05"},
+		{int64(42), "42"},
+	}
+
+	for _, tt := range tests {
+		var d Decimal
+		if err := d.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%#v): %v", tt.src, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Scan(%#v) = %s, want %s", tt.src, d, tt.want)
+		}
+
+		v, err := d.Value()
+		if err != nil {
+			t.Errorf("Value: %v", err)
+			continue
+		}
+		if v != tt.want {
+			t.Errorf("Value() = %#v, want %q", v, tt.want)
+		}
+	}
+
+	for _, src := range []any{nil, 1.5, "1e3", true} {
+		var d Decimal
+		if err := d.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %s, want an error", src, d)
+		}
+	}
+}
+
+func TestNullDecimal(t *testing.T) {
+	var n NullDecimal
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Fatalf("Scan(nil) = %+v, %v", n, err)
+	}
+	if v, err := n.Value(); v != nil || err != nil {
+		t.Errorf("Value() = %#v, %v, want nil", v, err)
+	}
+
+	if err := n.Scan("3.10"); err != nil || !n.Valid || n.Decimal.String() != "3.10" {
+		t.Fatalf("Scan(\"3.10\") = %+v, %v", n, err)
+	}
+}
@@ -0,0 +1,54 @@
+package rbac
+
+// Roles stored in users.role
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+type Permission string
+
+const (
+	// manage products owned by anyone, not just yourself
+	PermManageAnyProduct Permission = "products:manage_any"
+	// list, promote, disable and force-logout users
+	PermManageUsers Permission = "users:manage"
+	// see every order and move it through payment and fulfilment
+	PermManageOrders Permission = "orders:manage"
+	// create and change discount codes and promotions
+	PermManagePromotions Permission = "promotions:manage"
+	// approve, reject and delete anyone's product reviews
+	PermModerateReviews Permission = "reviews:moderate"
+)
+
+// rolePermissions is the single source of truth for what each role may do.
+// Plain users need no entry — owning a product is checked by the queries.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermManageAnyProduct,
+		PermManageUsers,
+		PermManageOrders,
+		PermManagePromotions,
+		PermModerateReviews,
+	},
+}
+
+// Permissions returns the permission set granted to a role
+func Permissions(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// HasPermission reports whether a role grants the permission
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one we know about
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
@@ -7,6 +7,14 @@ import (
 
 type successResponse struct {
 	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"` // pagination and other list metadata
+}
+
+// CursorMeta is the "meta" object for keyset-paginated lists.
+// NextCursor is null on the last page.
+type CursorMeta struct {
+	NextCursor *string `json:"next_cursor"`
+	Limit      int     `json:"limit"`
 }
 
 type errorResponse struct {
@@ -20,6 +28,13 @@ func JSON(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(successResponse{Data: data})
 }
 
+// JSONWithMeta is JSON plus a "meta" object alongside "data"
+func JSONWithMeta(w http.ResponseWriter, status int, data, meta any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(successResponse{Data: data, Meta: meta})
+}
+
 func Error(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -6,11 +6,15 @@ import (
 	"time"
 
 	_ "github.com/falasefemi2/goreact-boilerplate/docs"
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
 	"github.com/falasefemi2/goreact-boilerplate/internal/config"
 	"github.com/falasefemi2/goreact-boilerplate/internal/db"
 	"github.com/falasefemi2/goreact-boilerplate/internal/handler"
 	appMiddleware "github.com/falasefemi2/goreact-boilerplate/internal/middleware"
+	"github.com/falasefemi2/goreact-boilerplate/internal/rbac"
 	"github.com/falasefemi2/goreact-boilerplate/internal/service"
+	"github.com/falasefemi2/goreact-boilerplate/internal/storage"
+	"github.com/falasefemi2/goreact-boilerplate/internal/urlsign"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -18,7 +22,9 @@ import (
 	"golang.org/x/time/rate"
 )
 
-func New(database *sql.DB, cfg *config.Config) http.Handler {
+// New builds the router, along with the background jobs its services
+// need. The caller runs the jobs and cancels them on shutdown.
+func New(database *sql.DB, blobs storage.BlobStore, cfg *config.Config) (http.Handler, []background.Job) {
 	r := chi.NewRouter()
 
 	// Global middleware
@@ -26,9 +32,9 @@ func New(database *sql.DB, cfg *config.Config) http.Handler {
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{cfg.Server.AllowedOrigin},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "Accept-Patch", "ETag", "Idempotent-Replayed", "Content-Disposition"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -38,44 +44,296 @@ func New(database *sql.DB, cfg *config.Config) http.Handler {
 		cfg.Email.ResendAPIKey,
 		cfg.Email.FromEmail,
 	)
+	revocationStore := service.NewRevocationStore(queries, cfg.Auth.RevocationCacheTTL)
+	cartService := service.NewCartService(
+		database,
+		queries,
+		urlsign.New(cfg.Cart.CookieSecret, cfg.Cart.AnonymousTTL),
+		cfg.Cart.AnonymousTTL,
+	)
 	authService := service.NewAuthService(
 		queries,
-		cfg.Auth.JWTSecret,
+		service.AuthConfig{
+			JWTSecret:            cfg.Auth.JWTSecret,
+			AccessTokenTTL:       cfg.Auth.AccessTokenTTL,
+			RefreshTokenTTL:      cfg.Auth.RefreshTokenTTL,
+			EmailVerificationTTL: cfg.Auth.EmailVerificationTTL,
+			PasswordResetTTL:     cfg.Auth.PasswordResetTTL,
+			VerifyURL:            cfg.Email.VerifyURL,
+			ResetURL:             cfg.Email.ResetURL,
+		},
 		emailService,
+		revocationStore,
+		cartService,
 	)
 	authHandler := handler.NewAuthHandler(authService)
-	productService := service.NewProductService(queries)
-	productHandler := handler.NewProductHandler(productService)
+	cartHandler := handler.NewCartHandler(cartService)
+	productService := service.NewProductService(database, queries, cfg.Products.TrashRetention)
+	imageService := service.NewImageService(
+		queries,
+		blobs,
+		urlsign.New(cfg.Images.URLSecret, cfg.Images.URLTTL),
+		service.ImageConfig{
+			MaxBytes:      int64(cfg.Images.MaxBytes),
+			MaxPixels:     cfg.Images.MaxPixels,
+			ThumbnailSize: cfg.Images.ThumbnailSize,
+			MaxPerProduct: cfg.Images.MaxPerProduct,
+			BaseURL:       cfg.Images.BaseURL,
+		},
+	)
+	productHandler := handler.NewProductHandler(productService, imageService)
+	adminProductHandler := handler.NewAdminProductHandler(productService, imageService)
+	categoryHandler := handler.NewCategoryHandler(service.NewCategoryService(database, queries))
+	tagHandler := handler.NewTagHandler(service.NewTagService(queries))
+	orderService := service.NewOrderService(database, queries)
+	orderHandler := handler.NewOrderHandler(orderService)
+	adminOrderHandler := handler.NewAdminOrderHandler(orderService)
+	promotionHandler := handler.NewPromotionHandler(service.NewPromotionService(database, queries))
+	reviewService := service.NewReviewService(database, queries)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	adminReviewHandler := handler.NewAdminReviewHandler(reviewService)
+	catalogService := service.NewCatalogService(queries, cfg.Catalog.CacheTTL)
+	catalogHandler := handler.NewCatalogHandler(catalogService, cfg.Catalog.CacheTTL)
+	adminService := service.NewAdminService(database, queries, revocationStore)
+	adminHandler := handler.NewAdminHandler(adminService)
+
+	// Replays responses to retried POST/PATCH requests that carry an Idempotency-Key
+	idempotency := appMiddleware.NewIdempotency(queries, cfg.Server.IdempotencyKeyTTL)
+
+	jobs := []background.Job{
+		revocationStore.Cleanup,
+		productService.PurgeTrash,
+		productService.ApplyScheduledPrices,
+		productService.RunImports,
+		productService.FailStaleImports,
+		cartService.DeleteStaleCarts,
+		imageService.DeleteBlobs,
+		catalogService.EvictExpired,
+		idempotency.Cleanup,
+	}
 
 	// Strict limiter for auth — 5 requests/minute per IP
 	authLimiter := appMiddleware.NewRateLimiter(rate.Every(time.Minute/5), 5)
+	// One verification email per minute per user
+	resendLimiter := appMiddleware.NewRateLimiter(rate.Every(time.Minute), 1)
+	// Anonymous catalog browsing, per IP
+	catalogLimiter := appMiddleware.NewRateLimiter(
+		rate.Every(time.Minute/time.Duration(cfg.Catalog.RateLimit)),
+		cfg.Catalog.RateLimit,
+	)
+	// The cart, which anonymous shoppers can write to, per IP
+	cartLimiter := appMiddleware.NewRateLimiter(
+		rate.Every(time.Minute/time.Duration(cfg.Cart.RateLimit)),
+		cfg.Cart.RateLimit,
+	)
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("ok"))
 	})
 
+	// Signed image links; the signature stands in for a session
+	r.Get("/api/v1/images/*", productHandler.ServeImage)
+
 	// Public auth routes with rate limiting
 	r.Group(func(r chi.Router) {
 		r.Use(authLimiter.Limit)
 		r.Post("/api/v1/auth/register", authHandler.Register)
 		r.Post("/api/v1/auth/login", authHandler.Login)
+		r.Post("/api/v1/auth/refresh", authHandler.Refresh)
 		r.Post("/api/v1/auth/logout", authHandler.Logout)
+		r.Get("/api/v1/auth/verify", authHandler.VerifyEmail)
+		r.Post("/api/v1/auth/verify", authHandler.VerifyEmail)
+		r.Post("/api/v1/auth/password/forgot", authHandler.ForgotPassword)
+		r.Post("/api/v1/auth/password/reset", authHandler.ResetPassword)
 		r.Get("/docs/*", httpSwagger.Handler(
 			httpSwagger.URL("/docs/doc.json"),
 		))
 	})
 
+	// Public read-only catalog of published products
+	r.Group(func(r chi.Router) {
+		r.Use(catalogLimiter.Limit)
+		r.Get("/api/v1/catalog", catalogHandler.List)
+		r.Get("/api/v1/catalog/{id}", catalogHandler.GetByID)
+	})
+
+	// The cart works with or without a session. Anonymous shoppers have
+	// no user to key idempotency on, so it isn't used here.
+	r.Group(func(r chi.Router) {
+		r.Use(cartLimiter.Limit)
+		r.Use(appMiddleware.OptionalAuth(cfg.Auth.JWTSecret, revocationStore))
+		r.Get("/api/v1/cart", cartHandler.Get)
+		r.Post("/api/v1/cart/items", cartHandler.AddItem)
+		r.Put("/api/v1/cart/items/{product_id}", cartHandler.SetQuantity)
+		r.Delete("/api/v1/cart/items/{product_id}", cartHandler.RemoveItem)
+	})
+
 	// Protected routes
 	r.Group(func(r chi.Router) {
-		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret))
+		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret, revocationStore))
 		r.Get("/api/v1/auth/me", authHandler.Me)
-		r.Post("/api/v1/products", productHandler.Create)
-		r.Get("/api/v1/products", productHandler.List)
-		r.Get("/api/v1/products/{id}", productHandler.GetByID)
-		r.Put("/api/v1/products/{id}", productHandler.Update)
-		r.Delete("/api/v1/products/{id}", productHandler.Delete)
+		r.Post("/api/v1/auth/logout-all", authHandler.LogoutAll)
+		r.With(resendLimiter.LimitByUser).Post("/api/v1/auth/verify/resend", authHandler.ResendVerification)
+
+		// Routes that can be restricted to verified accounts
+		r.Group(func(r chi.Router) {
+			if cfg.Auth.RequireVerifiedEmail {
+				r.Use(appMiddleware.RequireVerifiedEmail)
+			}
+			// Uploads are too big to fingerprint, so they skip idempotency
+			r.Post("/api/v1/products/import", productHandler.Import)
+			r.Get("/api/v1/products/imports/{id}", productHandler.GetImport)
+			r.Post("/api/v1/products/{id}/images", productHandler.UploadImage)
+
+			r.Group(func(r chi.Router) {
+				r.Use(idempotency.Handle)
+				r.Post("/api/v1/products", productHandler.Create)
+				r.Get("/api/v1/products", productHandler.List)
+				r.Get("/api/v1/products/search", productHandler.Search)
+				r.Get("/api/v1/products/export", productHandler.Export)
+				r.Get("/api/v1/products/trash", productHandler.Trash)
+				r.Delete("/api/v1/products/trash/{id}", productHandler.Purge)
+				r.Post("/api/v1/products/{id}/restore", productHandler.Restore)
+				r.Get("/api/v1/products/{id}", productHandler.GetByID)
+				r.Get("/api/v1/products/{id}/categories", productHandler.Categories)
+				r.Put("/api/v1/products/{id}/categories", productHandler.SetCategories)
+				r.Get("/api/v1/products/{id}/tags", productHandler.Tags)
+				r.Put("/api/v1/products/{id}/tags", productHandler.SetTags)
+				r.Get("/api/v1/products/{id}/images", productHandler.Images)
+				r.Post("/api/v1/products/{id}/stock/adjust", productHandler.AdjustStock)
+				r.Get("/api/v1/products/{id}/stock/movements", productHandler.StockMovements)
+				r.Delete("/api/v1/products/{id}/images/{image_id}", productHandler.DeleteImage)
+				r.Get("/api/v1/products/{id}/variants", productHandler.Variants)
+				r.Post("/api/v1/products/{id}/variants", productHandler.CreateVariant)
+				r.Get("/api/v1/products/{id}/variants/{variant_id}", productHandler.GetVariant)
+				r.Put("/api/v1/products/{id}/variants/{variant_id}", productHandler.UpdateVariant)
+				r.Delete("/api/v1/products/{id}/variants/{variant_id}", productHandler.DeleteVariant)
+				r.Post("/api/v1/products/{id}/variants/{variant_id}/stock/adjust", productHandler.AdjustVariantStock)
+				r.Get("/api/v1/products/{id}/variants/{variant_id}/stock/movements", productHandler.VariantStockMovements)
+				r.Get("/api/v1/products/{id}/prices", productHandler.PriceHistory)
+				r.Get("/api/v1/products/{id}/prices/scheduled", productHandler.ScheduledPrices)
+				r.Post("/api/v1/products/{id}/prices/scheduled", productHandler.SchedulePrice)
+				r.Delete("/api/v1/products/{id}/prices/scheduled/{change_id}", productHandler.CancelScheduledPrice)
+
+				r.Get("/api/v1/categories", categoryHandler.List)
+				r.Post("/api/v1/categories", categoryHandler.Create)
+				r.Get("/api/v1/categories/{id}", categoryHandler.GetByID)
+				r.Put("/api/v1/categories/{id}", categoryHandler.Update)
+				r.Delete("/api/v1/categories/{id}", categoryHandler.Delete)
+
+				r.Get("/api/v1/tags", tagHandler.List)
+				r.Post("/api/v1/tags", tagHandler.Create)
+				r.Put("/api/v1/tags/{id}", tagHandler.Rename)
+				r.Delete("/api/v1/tags/{id}", tagHandler.Delete)
+
+				r.Post("/api/v1/orders", orderHandler.Create)
+				r.Get("/api/v1/orders", orderHandler.List)
+				r.Get("/api/v1/orders/{id}", orderHandler.GetByID)
+				r.Post("/api/v1/orders/{id}/cancel", orderHandler.Cancel)
+				r.Post("/api/v1/promotions/evaluate", promotionHandler.Evaluate)
+
+				r.Get("/api/v1/products/{id}/reviews", reviewHandler.List)
+				r.Post("/api/v1/products/{id}/reviews", reviewHandler.Create)
+				r.Put("/api/v1/reviews/{id}", reviewHandler.Update)
+				r.Delete("/api/v1/reviews/{id}", reviewHandler.Delete)
+
+				// Writes that must name the version they overwrite
+				r.Group(func(r chi.Router) {
+					if cfg.Server.RequireIfMatch {
+						r.Use(appMiddleware.RequireIfMatch)
+					}
+					r.Put("/api/v1/products/{id}", productHandler.Update)
+					r.Patch("/api/v1/products/{id}", productHandler.Patch)
+					r.Delete("/api/v1/products/{id}", productHandler.Delete)
+				})
+			})
+		})
+	})
+
+	// Admin routes — same handlers, no per-user filter
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret, revocationStore))
+		r.Use(appMiddleware.RequirePermission(rbac.PermManageAnyProduct))
+		r.Use(idempotency.Handle)
+		r.Get("/api/v1/admin/products", adminProductHandler.List)
+		r.Get("/api/v1/admin/products/search", adminProductHandler.Search)
+		r.Get("/api/v1/admin/products/export", adminProductHandler.Export)
+		r.Get("/api/v1/admin/products/trash", adminProductHandler.Trash)
+		r.Delete("/api/v1/admin/products/trash/{id}", adminProductHandler.Purge)
+		r.Post("/api/v1/admin/products/{id}/restore", adminProductHandler.Restore)
+		r.Get("/api/v1/admin/products/{id}", adminProductHandler.GetByID)
+		r.Get("/api/v1/admin/products/{id}/categories", adminProductHandler.Categories)
+		r.Put("/api/v1/admin/products/{id}/categories", adminProductHandler.SetCategories)
+		r.Get("/api/v1/admin/products/{id}/tags", adminProductHandler.Tags)
+		r.Put("/api/v1/admin/products/{id}/tags", adminProductHandler.SetTags)
+		r.Get("/api/v1/admin/products/{id}/images", adminProductHandler.Images)
+		r.Post("/api/v1/admin/products/{id}/stock/adjust", adminProductHandler.AdjustStock)
+		r.Get("/api/v1/admin/products/{id}/stock/movements", adminProductHandler.StockMovements)
+		r.Delete("/api/v1/admin/products/{id}/images/{image_id}", adminProductHandler.DeleteImage)
+		r.Get("/api/v1/admin/products/{id}/variants", adminProductHandler.Variants)
+		r.Post("/api/v1/admin/products/{id}/variants", adminProductHandler.CreateVariant)
+		r.Get("/api/v1/admin/products/{id}/variants/{variant_id}", adminProductHandler.GetVariant)
+		r.Put("/api/v1/admin/products/{id}/variants/{variant_id}", adminProductHandler.UpdateVariant)
+		r.Delete("/api/v1/admin/products/{id}/variants/{variant_id}", adminProductHandler.DeleteVariant)
+		r.Post("/api/v1/admin/products/{id}/variants/{variant_id}/stock/adjust", adminProductHandler.AdjustVariantStock)
+		r.Get("/api/v1/admin/products/{id}/variants/{variant_id}/stock/movements", adminProductHandler.VariantStockMovements)
+		r.Get("/api/v1/admin/products/{id}/prices", adminProductHandler.PriceHistory)
+		r.Get("/api/v1/admin/products/{id}/prices/scheduled", adminProductHandler.ScheduledPrices)
+		r.Post("/api/v1/admin/products/{id}/prices/scheduled", adminProductHandler.SchedulePrice)
+		r.Delete("/api/v1/admin/products/{id}/prices/scheduled/{change_id}", adminProductHandler.CancelScheduledPrice)
+
+		r.Group(func(r chi.Router) {
+			if cfg.Server.RequireIfMatch {
+				r.Use(appMiddleware.RequireIfMatch)
+			}
+			r.Put("/api/v1/admin/products/{id}", adminProductHandler.Update)
+			r.Patch("/api/v1/admin/products/{id}", adminProductHandler.Patch)
+			r.Delete("/api/v1/admin/products/{id}", adminProductHandler.Delete)
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret, revocationStore))
+		r.Use(appMiddleware.RequirePermission(rbac.PermManageOrders))
+		r.Use(idempotency.Handle)
+		r.Get("/api/v1/admin/orders", adminOrderHandler.List)
+		r.Get("/api/v1/admin/orders/{id}", adminOrderHandler.GetByID)
+		r.Post("/api/v1/admin/orders/{id}/status", adminOrderHandler.SetStatus)
+		r.Post("/api/v1/admin/orders/{id}/cancel", adminOrderHandler.Cancel)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret, revocationStore))
+		r.Use(appMiddleware.RequirePermission(rbac.PermManagePromotions))
+		r.Use(idempotency.Handle)
+		r.Get("/api/v1/admin/promotions", promotionHandler.List)
+		r.Post("/api/v1/admin/promotions", promotionHandler.Create)
+		r.Get("/api/v1/admin/promotions/{id}", promotionHandler.GetByID)
+		r.Put("/api/v1/admin/promotions/{id}", promotionHandler.Update)
+		r.Delete("/api/v1/admin/promotions/{id}", promotionHandler.Delete)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret, revocationStore))
+		r.Use(appMiddleware.RequirePermission(rbac.PermModerateReviews))
+		r.Use(idempotency.Handle)
+		r.Get("/api/v1/admin/reviews", adminReviewHandler.Queue)
+		r.Post("/api/v1/admin/reviews/{id}/status", adminReviewHandler.Moderate)
+		r.Delete("/api/v1/admin/reviews/{id}", adminReviewHandler.Delete)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.RequireAuth(cfg.Auth.JWTSecret, revocationStore))
+		r.Use(appMiddleware.RequirePermission(rbac.PermManageUsers))
+		r.Get("/api/v1/admin/users", adminHandler.ListUsers)
+		r.Get("/api/v1/admin/users/{id}", adminHandler.GetUser)
+		r.Put("/api/v1/admin/users/{id}/role", adminHandler.ChangeRole)
+		r.Post("/api/v1/admin/users/{id}/disable", adminHandler.DisableUser)
+		r.Post("/api/v1/admin/users/{id}/enable", adminHandler.EnableUser)
+		r.Post("/api/v1/admin/users/{id}/logout", adminHandler.ForceLogout)
+		r.Get("/api/v1/admin/users/{id}/audit", adminHandler.AuditLog)
 	})
 
-	return r
+	return r, jobs
 }
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/rbac"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrCannotModifySelf = errors.New("cannot perform this action on your own account")
+)
+
+// audit log actions
+const (
+	AuditRoleChanged = "user.role_changed"
+	AuditDisabled    = "user.disabled"
+	AuditEnabled     = "user.enabled"
+	AuditForceLogout = "user.force_logout"
+)
+
+type AdminService struct {
+	database    *sql.DB
+	queries     db.Querier
+	revocations *RevocationStore
+}
+
+func NewAdminService(database *sql.DB, queries db.Querier, revocations *RevocationStore) *AdminService {
+	return &AdminService{
+		database:    database,
+		queries:     queries,
+		revocations: revocations,
+	}
+}
+
+type UserPage struct {
+	Users []db.User
+	Total int64
+}
+
+func (s *AdminService) ListUsers(ctx context.Context, page Page) (UserPage, error) {
+	users, err := s.queries.ListUsers(ctx, db.ListUsersParams{
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return UserPage{}, err
+	}
+
+	total, err := s.queries.CountUsers(ctx)
+	if err != nil {
+		return UserPage{}, err
+	}
+
+	return UserPage{Users: users, Total: total}, nil
+}
+
+func (s *AdminService) GetUser(ctx context.Context, userID string) (db.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.User{}, ErrUserNotFound
+	}
+
+	user, err := s.queries.GetUserByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, ErrUserNotFound
+		}
+		return db.User{}, err
+	}
+
+	return user, nil
+}
+
+// ChangeRole updates the user's role. Their access tokens are invalidated
+// so the new role applies on their next refresh; refresh tokens survive,
+// so they aren't logged out.
+func (s *AdminService) ChangeRole(ctx context.Context, adminID, userID, role string) (db.User, error) {
+	if !rbac.ValidRole(role) {
+		return db.User{}, ErrInvalidRole
+	}
+
+	var user db.User
+	err := s.act(ctx, adminID, userID, func(q *db.Queries, target uuid.UUID) (string, any, error) {
+		before, err := q.GetUserByID(ctx, target)
+		if err != nil {
+			return "", nil, err
+		}
+
+		user, err = q.UpdateUserRole(ctx, db.UpdateUserRoleParams{Role: role, ID: target})
+		if err != nil {
+			return "", nil, err
+		}
+
+		if _, err := q.IncrementUserTokenVersion(ctx, target); err != nil {
+			return "", nil, err
+		}
+
+		return AuditRoleChanged, map[string]string{"from": before.Role, "to": role}, nil
+	})
+	return user, err
+}
+
+// DisableUser blocks the account from logging in and kills every
+// session it currently has
+func (s *AdminService) DisableUser(ctx context.Context, adminID, userID string) (db.User, error) {
+	var user db.User
+	err := s.act(ctx, adminID, userID, func(q *db.Queries, target uuid.UUID) (string, any, error) {
+		var err error
+		user, err = q.DisableUser(ctx, target)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if err := revokeSessions(ctx, q, target); err != nil {
+			return "", nil, err
+		}
+
+		return AuditDisabled, nil, nil
+	})
+	return user, err
+}
+
+func (s *AdminService) EnableUser(ctx context.Context, adminID, userID string) (db.User, error) {
+	var user db.User
+	err := s.act(ctx, adminID, userID, func(q *db.Queries, target uuid.UUID) (string, any, error) {
+		var err error
+		user, err = q.EnableUser(ctx, target)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return AuditEnabled, nil, nil
+	})
+	return user, err
+}
+
+// ForceLogout revokes every access and refresh token the user holds
+func (s *AdminService) ForceLogout(ctx context.Context, adminID, userID string) error {
+	return s.act(ctx, adminID, userID, func(q *db.Queries, target uuid.UUID) (string, any, error) {
+		if _, err := q.GetUserByID(ctx, target); err != nil {
+			return "", nil, err
+		}
+
+		if err := revokeSessions(ctx, q, target); err != nil {
+			return "", nil, err
+		}
+
+		return AuditForceLogout, nil, nil
+	})
+}
+
+func (s *AdminService) AuditLog(ctx context.Context, userID string, page Page) ([]db.AdminAuditLog, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return s.queries.ListAdminAuditLogsByTarget(ctx, db.ListAdminAuditLogsByTargetParams{
+		TargetUserID: uid,
+		Limit:        page.Limit(),
+		Offset:       page.Offset(),
+	})
+}
+
+// act runs an admin action and its audit entry in one transaction.
+// fn returns the audit action and any details worth recording.
+// Admins can't act on themselves — it's too easy to lock yourself out.
+func (s *AdminService) act(
+	ctx context.Context,
+	adminID, userID string,
+	fn func(q *db.Queries, target uuid.UUID) (string, any, error),
+) error {
+	admin, err := uuid.Parse(adminID)
+	if err != nil {
+		return ErrForbidden
+	}
+	target, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if admin == target {
+		return ErrCannotModifySelf
+	}
+
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		action, details, err := fn(q, target)
+		if err != nil {
+			return err
+		}
+
+		if details == nil {
+			details = map[string]string{}
+		}
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateAdminAuditLog(ctx, db.CreateAdminAuditLogParams{
+			AdminID:      admin,
+			TargetUserID: target,
+			Action:       action,
+			Details:      raw,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// the transaction changed token_version or disabled_at behind
+	// the store's back
+	s.revocations.Forget(target)
+	return nil
+}
+
+// revokeSessions does what AuthService.revokeAllSessions does,
+// inside the caller's transaction
+func revokeSessions(ctx context.Context, q *db.Queries, userID uuid.UUID) error {
+	if _, err := q.IncrementUserTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+
+	return q.RevokeUserRefreshTokens(ctx, userID)
+}
@@ -2,59 +2,420 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"log/slog"
+	"net/url"
 	"time"
 
 	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/rbac"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrEmailTaken   = errors.New("email already in use")
-	ErrInvalidCreds = errors.New("invalid email or password")
+	ErrEmailTaken          = errors.New("email already in use")
+	ErrInvalidCreds        = errors.New("invalid email or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrInvalidVerification = errors.New("invalid or expired verification token")
+	ErrAlreadyVerified     = errors.New("email already verified")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset token")
+	ErrAccountDisabled     = errors.New("account disabled")
 )
 
+// token "typ" claims — an access token must never be accepted
+// where a verification token is expected, and vice versa. The auth
+// middleware checks for TokenTypeAccess too.
+const (
+	TokenTypeAccess      = "access"
+	tokenTypeVerifyEmail = "verify_email"
+)
+
+// AuthConfig holds the token settings AuthService needs
+type AuthConfig struct {
+	JWTSecret            string
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	// VerifyURL and ResetURL are where emailed links point; the token
+	// is appended as the "token" query parameter
+	VerifyURL string
+	ResetURL  string
+}
+
 type AuthService struct {
 	queries      db.Querier
-	jwtSecret    string
+	cfg          AuthConfig
 	emailService *EmailService
+	revocations  *RevocationStore
+	carts        *CartService
 }
 
-func NewAuthService(queries db.Querier, jwtSecret string, emailService *EmailService) *AuthService {
+func NewAuthService(queries db.Querier, cfg AuthConfig, emailService *EmailService, revocations *RevocationStore, carts *CartService) *AuthService {
 	return &AuthService{
 		queries:      queries,
-		jwtSecret:    jwtSecret,
+		cfg:          cfg,
 		emailService: emailService,
+		revocations:  revocations,
+		carts:        carts,
 	}
 }
 
-func (s *AuthService) Register(ctx context.Context, email, password string) (string, error) {
+// TokenPair is what a successful login hands back to the client:
+// a short-lived access JWT and an opaque, rotating refresh token.
+type TokenPair struct {
+	AccessToken     string
+	AccessTokenTTL  time.Duration
+	RefreshToken    string
+	RefreshTokenTTL time.Duration
+}
+
+func (s *AuthService) Register(ctx context.Context, email, password string) (TokenPair, error) {
 	// check if email is taken
 	exiting, _ := s.queries.GetUserByEmail(ctx, email)
 	if exiting.ID != [16]byte{} {
-		return "", ErrEmailTaken
+		return TokenPair{}, ErrEmailTaken
 	}
 
 	// Hash the password
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", err
+		return TokenPair{}, err
 	}
 
 	// create user
 	user, err := s.queries.CreateUser(ctx, db.CreateUserParams{
 		Email:    email,
 		Password: string(hashed),
-		Role:     "user",
+		Role:     rbac.RoleUser,
 	})
 	if err != nil {
-		return "", err
+		return TokenPair{}, err
+	}
+
+	// the welcome email goes out once the address is confirmed
+	if err := s.sendVerification(ctx, user); err != nil {
+		return TokenPair{}, err
+	}
+
+	// start a fresh refresh token family for this login
+	return s.issueTokenPair(ctx, user, uuid.New())
+}
+
+// Login checks the credentials and starts a new session. cartToken is
+// the shopper's anonymous cart cookie, if any; its items are merged
+// into the user's cart. A failed merge doesn't fail the login, but
+// cartMerged is false so the caller keeps the cookie and the cart
+// isn't lost.
+func (s *AuthService) Login(ctx context.Context, email, password, cartToken string) (tokens TokenPair, cartMerged bool, err error) {
+	user, err := s.queries.GetUserByEmail(ctx, email)
+	if err != nil {
+		return TokenPair{}, false, ErrInvalidCreds
+	}
+
+	// Compare submitted password with stored hash
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return TokenPair{}, false, ErrInvalidCreds
+	}
+
+	// only tell the caller the account is disabled once they've
+	// proven they own it
+	if user.DisabledAt.Valid {
+		return TokenPair{}, false, ErrAccountDisabled
+	}
+
+	cartMerged = true
+	if cartToken != "" {
+		if err := s.carts.Merge(ctx, user.ID, cartToken); err != nil {
+			slog.Error("merging anonymous cart", "user_id", user.ID, "error", err)
+			cartMerged = false
+		}
+	}
+
+	tokens, err = s.issueTokenPair(ctx, user, uuid.New())
+	if err != nil {
+		return TokenPair{}, false, err
+	}
+	return tokens, cartMerged, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+// Each refresh token can be used exactly once. Presenting one that has
+// already been rotated means it leaked, so the whole family is revoked
+// and the user has to log in again.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	stored, err := s.queries.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenPair{}, ErrInvalidRefreshToken
+		}
+		return TokenPair{}, err
+	}
+
+	// revoked without a successor means logout, not rotation
+	if stored.RevokedAt.Valid && !stored.ReplacedBy.Valid {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt.Valid {
+		if err := s.queries.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		slog.Warn("refresh token reuse detected",
+			"user_id", stored.UserID,
+			"family_id", stored.FamilyID,
+		)
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	user, err := s.queries.GetUserByID(ctx, stored.UserID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if user.DisabledAt.Valid {
+		if err := s.queries.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrAccountDisabled
+	}
+
+	// issue the replacement first so the old token can point at it
+	pair, next, err := s.newTokenPair(ctx, user, stored.FamilyID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	rotated, err := s.queries.RotateRefreshToken(ctx, db.RotateRefreshTokenParams{
+		ReplacedBy: uuid.NullUUID{UUID: next.ID, Valid: true},
+		ID:         stored.ID,
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// another request rotated this token between our read and write —
+	// treat it exactly like reuse
+	if rotated == 0 {
+		if err := s.queries.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrRefreshTokenReused
+	}
+
+	return pair, nil
+}
+
+// Logout revokes the presented access token and the refresh token
+// family it came with. Unknown or malformed tokens are ignored —
+// there is nothing to revoke.
+func (s *AuthService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	if accessToken != "" {
+		if err := s.revokeAccessToken(ctx, accessToken); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.queries.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	return s.queries.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+}
+
+// LogoutEverywhere invalidates every access and refresh token
+// the user currently holds, on every device
+func (s *AuthService) LogoutEverywhere(ctx context.Context, userID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrForbidden
+	}
+
+	return s.revokeAllSessions(ctx, uid)
+}
+
+// ForgotPassword emails a reset link if the address belongs to an account.
+// The lookup and email happen after we return, so the response takes the
+// same time whether or not the email exists and can't be used to probe
+// for accounts.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			slog.Error("failed to send password reset", "error", err)
+		}
+	}()
+}
+
+// ResetPassword sets a new password using an emailed reset token and
+// logs the user out of every existing session
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) error {
+	userID, err := s.queries.ConsumePasswordResetToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Password: string(hashed),
+		ID:       userID,
+	}); err != nil {
+		return err
+	}
+
+	// any other outstanding links are now stale
+	if err := s.queries.InvalidateUserPasswordResetTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.revokeAllSessions(ctx, userID)
+}
+
+func (s *AuthService) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.queries.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	link, err := withToken(s.cfg.ResetURL, token)
+	if err != nil {
+		return err
+	}
+
+	return s.emailService.SendPasswordReset(user.Email, link)
+}
+
+// revokeAllSessions bumps the user's token version and revokes every
+// refresh token, so nothing issued before now keeps working
+func (s *AuthService) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.revocations.RevokeAll(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.queries.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// revokeAccessToken blacklists the token's jti until it expires.
+// Expired tokens are still accepted here — logging out with one
+// should not fail.
+func (s *AuthService) revokeAccessToken(ctx context.Context, accessToken string) error {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(accessToken, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(s.cfg.JWTSecret), nil
+	}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil
+	}
+
+	if typ, _ := claims["typ"].(string); typ != TokenTypeAccess {
+		return nil
+	}
+
+	jtiStr, _ := claims["jti"].(string)
+	subStr, _ := claims.GetSubject()
+	exp, _ := claims.GetExpirationTime()
+
+	jti, err := uuid.Parse(jtiStr)
+	if err != nil {
+		return nil
+	}
+	userID, err := uuid.Parse(subStr)
+	if err != nil || exp == nil || time.Now().After(exp.Time) {
+		return nil
+	}
+
+	return s.revocations.Revoke(ctx, jti, userID, exp.Time)
+}
+
+// VerifyEmail consumes a verification token and marks the address verified.
+// Each token works once; a resend invalidates the ones sent before it.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(s.cfg.JWTSecret), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return ErrInvalidVerification
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenTypeVerifyEmail {
+		return ErrInvalidVerification
+	}
+	jtiStr, _ := claims["jti"].(string)
+	jti, err := uuid.Parse(jtiStr)
+	if err != nil {
+		return ErrInvalidVerification
+	}
+
+	userID, err := s.queries.ConsumeEmailVerificationToken(ctx, jti)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerification
+		}
+		return err
+	}
+
+	if err := s.queries.MarkUserEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
 	}
 
 	// Send welcome email — non-blocking
-	// we don't fail registration if email fails
+	// we don't fail verification if email fails
 	go func() {
 		if err := s.emailService.SendWelcome(user.Email); err != nil {
 			slog.Error("failed to send welcome email",
@@ -64,31 +425,149 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (str
 		}
 	}()
 
-	// return jwt token
-	return s.generateToken(user.ID.String())
+	return nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
-	user, err := s.queries.GetUserByEmail(ctx, email)
+// ResendVerification invalidates any outstanding verification
+// tokens for the user and emails a fresh one
+func (s *AuthService) ResendVerification(ctx context.Context, userID string) error {
+	uid, err := uuid.Parse(userID)
 	if err != nil {
-		return "", ErrInvalidCreds
+		return ErrForbidden
 	}
 
-	// Compare submitted password with stored hash
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", ErrInvalidCreds
+	user, err := s.queries.GetUserByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return ErrAlreadyVerified
+	}
+
+	if err := s.queries.InvalidateUserEmailVerificationTokens(ctx, uid); err != nil {
+		return err
 	}
 
-	return s.generateToken(user.ID.String())
+	return s.sendVerification(ctx, user)
 }
 
-func (s *AuthService) generateToken(userID string) (string, error) {
+// sendVerification records a single-use verification token and
+// emails the link in the background
+func (s *AuthService) sendVerification(ctx context.Context, user db.User) error {
+	jti := uuid.New()
+	expiresAt := time.Now().Add(s.cfg.EmailVerificationTTL)
+
+	if err := s.queries.CreateEmailVerificationToken(ctx, db.CreateEmailVerificationTokenParams{
+		Jti:       jti,
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
 	claims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+		"sub": user.ID.String(),
+		"jti": jti.String(),
+		"typ": tokenTypeVerifyEmail,
+		"exp": expiresAt.Unix(),
 		"iat": time.Now().Unix(),
 	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return err
+	}
+
+	link, err := withToken(s.cfg.VerifyURL, token)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.emailService.SendVerification(user.Email, link); err != nil {
+			slog.Error("failed to send verification email",
+				"email", user.Email,
+				"error", err,
+			)
+		}
+	}()
+
+	return nil
+}
+
+func (s *AuthService) issueTokenPair(ctx context.Context, user db.User, familyID uuid.UUID) (TokenPair, error) {
+	pair, _, err := s.newTokenPair(ctx, user, familyID)
+	return pair, err
+}
+
+func (s *AuthService) newTokenPair(ctx context.Context, user db.User, familyID uuid.UUID) (TokenPair, db.RefreshToken, error) {
+	accessToken, err := s.generateToken(user)
+	if err != nil {
+		return TokenPair{}, db.RefreshToken{}, err
+	}
+
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return TokenPair{}, db.RefreshToken{}, err
+	}
+
+	stored, err := s.queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		return TokenPair{}, db.RefreshToken{}, err
+	}
+
+	return TokenPair{
+		AccessToken:     accessToken,
+		AccessTokenTTL:  s.cfg.AccessTokenTTL,
+		RefreshToken:    refreshToken,
+		RefreshTokenTTL: s.cfg.RefreshTokenTTL,
+	}, stored, nil
+}
+
+func (s *AuthService) generateToken(user db.User) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  user.ID.String(),
+		"jti":  uuid.NewString(),
+		"typ":  TokenTypeAccess,
+		"ver":  user.TokenVersion,
+		"evf":  user.EmailVerifiedAt.Valid,
+		"role": user.Role,
+		"exp":  time.Now().Add(s.cfg.AccessTokenTTL).Unix(),
+		"iat":  time.Now().Unix(),
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return token.SignedString([]byte(s.cfg.JWTSecret))
+}
+
+// generateOpaqueToken returns 32 random bytes, URL-safe encoded
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// withToken appends the token to base as the "token" query parameter
+func withToken(base, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+	return link.String(), nil
+}
+
+// hashToken is what we store instead of the raw token,
+// so a leaked table can't be replayed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
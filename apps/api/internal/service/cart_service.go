@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/falasefemi2/goreact-boilerplate/internal/urlsign"
+	"github.com/google/uuid"
+)
+
+// cart item problems found when the cart is read
+const (
+	// the product has been moved to the trash or unpublished
+	CartIssueUnavailable = "unavailable"
+	// there's less stock than the cart asks for
+	CartIssueInsufficientStock = "insufficient_stock"
+	// the price is no longer the one the item was added at
+	CartIssuePriceChanged = "price_changed"
+	// the product is now priced in a different currency from the
+	// rest of the cart
+	CartIssueCurrencyMismatch = "currency_mismatch"
+)
+
+var ErrCartItemNotFound = errors.New("product is not in the cart")
+
+type CartService struct {
+	database     *sql.DB
+	queries      db.Querier
+	signer       *urlsign.Signer
+	anonymousTTL time.Duration
+}
+
+// NewCartService makes a service whose anonymous cart cookies are
+// signed with signer and last anonymousTTL. DeleteStaleCarts drops the
+// carts once they've gone that long untouched.
+func NewCartService(database *sql.DB, queries db.Querier, signer *urlsign.Signer, anonymousTTL time.Duration) *CartService {
+	return &CartService{
+		database:     database,
+		queries:      queries,
+		signer:       signer,
+		anonymousTTL: anonymousTTL,
+	}
+}
+
+// DeleteStaleCarts is a Job that deletes, every hour, anonymous carts
+// nobody has touched for anonymousTTL
+func (s *CartService) DeleteStaleCarts(ctx context.Context) {
+	background.Every(ctx, time.Hour, func(ctx context.Context) {
+		if _, err := s.queries.DeleteStaleAnonymousCarts(ctx, time.Now().Add(-s.anonymousTTL)); err != nil {
+			slog.Error("deleting stale carts", "error", err)
+		}
+	})
+}
+
+// CartOwner says whose cart a call works on: the logged-in user's, or
+// else the anonymous one the token points at (which may be empty)
+type CartOwner struct {
+	UserID string
+	Token  string
+}
+
+// buyer is the user behind the cart, uuid.Nil for anonymous shoppers
+func (o CartOwner) buyer() uuid.UUID {
+	id, _ := uuid.Parse(o.UserID)
+	return id
+}
+
+// Cart is the cart as it reads right now, checked against the live
+// products
+type Cart struct {
+	ID    uuid.UUID  `json:"id"`
+	Items []CartItem `json:"items"`
+	// Subtotal adds up every item that can still be bought, at today's
+	// prices. Its currency is the cart's: that of the first item.
+	Subtotal money.Money `json:"subtotal"`
+
+	// Token is set for anonymous carts: the cookie value that finds
+	// this cart again, valid until TokenExpires
+	Token        string    `json:"-"`
+	TokenExpires time.Time `json:"-"`
+}
+
+type CartItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	Quantity  int32     `json:"quantity"`
+	// UnitPrice is the current price; AddedPrice what it was when the
+	// item was last added
+	UnitPrice  money.Decimal `json:"unit_price"`
+	AddedPrice money.Decimal `json:"added_price"`
+	LineTotal  money.Decimal `json:"line_total"`
+	Currency   string        `json:"currency"`
+	// InStock is all shoppers learn of the stock; an item wanting more
+	// than there is has CartIssueInsufficientStock
+	InStock bool     `json:"in_stock"`
+	Issues  []string `json:"issues"`
+}
+
+// Get reads the cart. A shopper without one gets an empty cart; nothing
+// is created until they add something.
+func (s *CartService) Get(ctx context.Context, owner CartOwner) (Cart, error) {
+	cart, err := s.find(ctx, s.queries, owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Cart{Items: []CartItem{}, Subtotal: money.Zero(money.DefaultCurrency)}, nil
+		}
+		return Cart{}, err
+	}
+
+	return s.read(ctx, s.queries, cart)
+}
+
+// AddItem puts quantity of the product in the cart, on top of any
+// already there, creating the cart if need be. Only products that are
+// for sale and out of the trash can be added, and only in the currency
+// of the rest of the cart.
+func (s *CartService) AddItem(ctx context.Context, owner CartOwner, productID string, quantity int32) (Cart, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return Cart{}, ErrProductUnavailable
+	}
+
+	var result Cart
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		product, err := q.GetProductByID(ctx, db.GetProductByIDParams{ID: pid})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrProductUnavailable
+			}
+			return err
+		}
+		if !forSale(product, owner.buyer()) {
+			return ErrProductUnavailable
+		}
+
+		cart, err := s.findOrCreate(ctx, q, owner)
+		if err != nil {
+			return err
+		}
+
+		items, err := q.ListCartItems(ctx, cart.ID)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if !item.DeletedAt.Valid && item.ProductID != product.ID && item.Currency != product.Currency {
+				return money.ErrCurrencyMismatch
+			}
+		}
+
+		if _, err := q.AddCartItem(ctx, db.AddCartItemParams{
+			CartID:    cart.ID,
+			ProductID: product.ID,
+			Quantity:  quantity,
+			UnitPrice: product.Price,
+		}); err != nil {
+			return err
+		}
+
+		result, err = s.read(ctx, q, cart)
+		return err
+	})
+	return result, err
+}
+
+// SetQuantity replaces the quantity of a product already in the cart
+func (s *CartService) SetQuantity(ctx context.Context, owner CartOwner, productID string, quantity int32) (Cart, error) {
+	return s.changeItem(ctx, owner, productID, func(q *db.Queries, cart db.Cart, pid uuid.UUID) error {
+		_, err := q.SetCartItemQuantity(ctx, db.SetCartItemQuantityParams{
+			CartID:    cart.ID,
+			ProductID: pid,
+			Quantity:  quantity,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCartItemNotFound
+		}
+		return err
+	})
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, owner CartOwner, productID string) (Cart, error) {
+	return s.changeItem(ctx, owner, productID, func(q *db.Queries, cart db.Cart, pid uuid.UUID) error {
+		n, err := q.RemoveCartItem(ctx, db.RemoveCartItemParams{CartID: cart.ID, ProductID: pid})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrCartItemNotFound
+		}
+		return nil
+	})
+}
+
+// Merge moves the anonymous cart behind token into the user's cart and
+// deletes it. Quantities of products in both add up. An invalid or
+// expired token, or one whose cart is gone, is nothing to merge.
+func (s *CartService) Merge(ctx context.Context, userID uuid.UUID, token string) error {
+	anonID, ok := s.parseToken(token)
+	if !ok {
+		return nil
+	}
+
+	return withTx(ctx, s.database, func(q *db.Queries) error {
+		anon, err := q.GetAnonymousCart(ctx, anonID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		cart, err := q.UpsertUserCart(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		if err := q.MergeCartItems(ctx, db.MergeCartItemsParams{
+			IntoCart: cart.ID,
+			FromCart: anon.ID,
+		}); err != nil {
+			return err
+		}
+
+		return q.DeleteCart(ctx, anon.ID)
+	})
+}
+
+// changeItem runs fn against an existing cart and returns the result
+func (s *CartService) changeItem(
+	ctx context.Context,
+	owner CartOwner,
+	productID string,
+	fn func(q *db.Queries, cart db.Cart, productID uuid.UUID) error,
+) (Cart, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return Cart{}, ErrCartItemNotFound
+	}
+
+	var result Cart
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		cart, err := s.find(ctx, q, owner)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCartItemNotFound
+			}
+			return err
+		}
+
+		if err := fn(q, cart, pid); err != nil {
+			return err
+		}
+		if err := q.TouchCart(ctx, cart.ID); err != nil {
+			return err
+		}
+
+		result, err = s.read(ctx, q, cart)
+		return err
+	})
+	return result, err
+}
+
+// find returns the owner's cart, or sql.ErrNoRows if they have none
+func (s *CartService) find(ctx context.Context, q db.Querier, owner CartOwner) (db.Cart, error) {
+	if owner.UserID != "" {
+		uid, err := uuid.Parse(owner.UserID)
+		if err != nil {
+			return db.Cart{}, ErrForbidden
+		}
+		return q.GetUserCart(ctx, uuid.NullUUID{UUID: uid, Valid: true})
+	}
+
+	id, ok := s.parseToken(owner.Token)
+	if !ok {
+		return db.Cart{}, sql.ErrNoRows
+	}
+	return q.GetAnonymousCart(ctx, id)
+}
+
+func (s *CartService) findOrCreate(ctx context.Context, q db.Querier, owner CartOwner) (db.Cart, error) {
+	if owner.UserID != "" {
+		uid, err := uuid.Parse(owner.UserID)
+		if err != nil {
+			return db.Cart{}, ErrForbidden
+		}
+		return q.UpsertUserCart(ctx, uuid.NullUUID{UUID: uid, Valid: true})
+	}
+
+	cart, err := s.find(ctx, q, owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return q.CreateAnonymousCart(ctx)
+	}
+	if err != nil {
+		return db.Cart{}, err
+	}
+	return cart, q.TouchCart(ctx, cart.ID)
+}
+
+// read loads the cart's items and checks each against its product
+func (s *CartService) read(ctx context.Context, q db.Querier, cart db.Cart) (Cart, error) {
+	rows, err := q.ListCartItems(ctx, cart.ID)
+	if err != nil {
+		return Cart{}, err
+	}
+
+	result := Cart{ID: cart.ID, Items: make([]CartItem, 0, len(rows))}
+	var subtotal *money.Money
+	for _, row := range rows {
+		line := money.New(row.Price, row.Currency).Times(int64(row.Quantity))
+		item := CartItem{
+			ProductID:  row.ProductID,
+			Name:       row.Name,
+			Quantity:   row.Quantity,
+			UnitPrice:  row.Price,
+			AddedPrice: row.AddedPrice,
+			LineTotal:  line.Amount,
+			Currency:   row.Currency,
+			InStock:    row.Stock > 0,
+			Issues:     []string{},
+		}
+		if !row.Price.Equal(row.AddedPrice) {
+			item.Issues = append(item.Issues, CartIssuePriceChanged)
+		}
+
+		forSale := row.IsPublished || (cart.UserID.Valid && row.SellerID == cart.UserID.UUID)
+		switch {
+		case row.DeletedAt.Valid || !forSale:
+			item.Issues = append(item.Issues, CartIssueUnavailable)
+		case subtotal != nil && subtotal.Currency != row.Currency:
+			// the owner has changed its currency since it was added
+			item.Issues = append(item.Issues, CartIssueCurrencyMismatch)
+		default:
+			if row.Stock < row.Quantity {
+				item.Issues = append(item.Issues, CartIssueInsufficientStock)
+			}
+			if subtotal == nil {
+				subtotal = &line
+			} else {
+				sum, err := subtotal.Add(line)
+				if err != nil {
+					return Cart{}, err
+				}
+				subtotal = &sum
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if subtotal != nil {
+		result.Subtotal = *subtotal
+	} else {
+		result.Subtotal = money.Zero(money.DefaultCurrency)
+	}
+
+	if !cart.UserID.Valid {
+		result.Token, result.TokenExpires = s.token(cart.ID)
+	}
+	return result, nil
+}
+
+// token is the signed cookie value for an anonymous cart
+func (s *CartService) token(id uuid.UUID) (string, time.Time) {
+	q, expires := s.signer.Sign(cartTokenPath(id))
+	q.Set("cart", id.String())
+	return q.Encode(), expires
+}
+
+func (s *CartService) parseToken(token string) (uuid.UUID, bool) {
+	if token == "" {
+		return uuid.UUID{}, false
+	}
+	q, err := url.ParseQuery(token)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	id, err := uuid.Parse(q.Get("cart"))
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	if _, err := s.signer.Verify(cartTokenPath(id), q); err != nil {
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+func cartTokenPath(id uuid.UUID) string {
+	return "cart/" + id.String()
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+type cachedCatalogPage struct {
+	page      CatalogPage
+	expiresAt time.Time
+}
+
+type cachedCatalogProduct struct {
+	product   db.CatalogProduct
+	expiresAt time.Time
+}
+
+// CatalogService is the public, read-only side of products. It only
+// reads the catalog_products view, so unpublished and trashed products,
+// owners and exact stock never leave the database. Results are cached
+// in-process for cacheTTL: anonymous traffic can be heavy, and a
+// product published or changed shows up within cacheTTL.
+type CatalogService struct {
+	queries  db.Querier
+	cacheTTL time.Duration
+
+	mu       sync.RWMutex
+	pages    map[Page]cachedCatalogPage
+	products map[uuid.UUID]cachedCatalogProduct
+}
+
+func NewCatalogService(queries db.Querier, cacheTTL time.Duration) *CatalogService {
+	return &CatalogService{
+		queries:  queries,
+		cacheTTL: cacheTTL,
+		pages:    make(map[Page]cachedCatalogPage),
+		products: make(map[uuid.UUID]cachedCatalogProduct),
+	}
+}
+
+type CatalogPage struct {
+	Products []db.CatalogProduct
+	Total    int64
+}
+
+// List returns a page of published products, newest first
+func (s *CatalogService) List(ctx context.Context, page Page) (CatalogPage, error) {
+	s.mu.RLock()
+	cached, ok := s.pages[page]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.page, nil
+	}
+
+	products, err := s.queries.ListCatalogProducts(ctx, db.ListCatalogProductsParams{
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return CatalogPage{}, err
+	}
+
+	total, err := s.queries.CountCatalogProducts(ctx)
+	if err != nil {
+		return CatalogPage{}, err
+	}
+
+	result := CatalogPage{Products: products, Total: total}
+	s.mu.Lock()
+	s.pages[page] = cachedCatalogPage{page: result, expiresAt: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return result, nil
+}
+
+// Get returns a published product. Unpublished and trashed products
+// are ErrProductNotFound, the same as ones that don't exist.
+func (s *CatalogService) Get(ctx context.Context, productID string) (db.CatalogProduct, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return db.CatalogProduct{}, ErrProductNotFound
+	}
+
+	s.mu.RLock()
+	cached, ok := s.products[pid]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.product, nil
+	}
+
+	product, err := s.queries.GetCatalogProduct(ctx, pid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.CatalogProduct{}, ErrProductNotFound
+		}
+		return db.CatalogProduct{}, err
+	}
+
+	s.mu.Lock()
+	s.products[pid] = cachedCatalogProduct{product: product, expiresAt: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return product, nil
+}
+
+// EvictExpired is a Job that drops expired cache entries every minute,
+// so pages and products nobody asks for again don't pile up
+func (s *CatalogService) EvictExpired(ctx context.Context) {
+	background.Every(ctx, time.Minute, func(context.Context) {
+		now := time.Now()
+		s.mu.Lock()
+		for page, cached := range s.pages {
+			if now.After(cached.expiresAt) {
+				delete(s.pages, page)
+			}
+		}
+		for id, cached := range s.products {
+			if now.After(cached.expiresAt) {
+				delete(s.products, id)
+			}
+		}
+		s.mu.Unlock()
+	})
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryExists      = errors.New("a category with this name already exists here")
+	ErrCategoryCycle       = errors.New("category cannot be moved under itself or its subcategories")
+	ErrCategoryHasChildren = errors.New("category still has subcategories")
+	// ErrInvalidCategory is a reference (a parent, or a category being
+	// assigned to a product) that the user doesn't own
+	ErrInvalidCategory = errors.New("unknown category")
+)
+
+type CategoryService struct {
+	database *sql.DB
+	queries  db.Querier
+}
+
+func NewCategoryService(database *sql.DB, queries db.Querier) *CategoryService {
+	return &CategoryService{
+		database: database,
+		queries:  queries,
+	}
+}
+
+// CategoryInput describes a category. An empty ParentID makes it a
+// top-level category.
+type CategoryInput struct {
+	Name     string
+	ParentID string
+}
+
+// List returns the user's categories as a flat list; each one points
+// at its parent, so clients can build the tree
+func (s *CategoryService) List(ctx context.Context, userID string) ([]db.Category, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrForbidden
+	}
+
+	return s.queries.ListCategories(ctx, uid)
+}
+
+func (s *CategoryService) Get(ctx context.Context, userID, categoryID string) (db.Category, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Category{}, ErrCategoryNotFound
+	}
+	cid, err := uuid.Parse(categoryID)
+	if err != nil {
+		return db.Category{}, ErrCategoryNotFound
+	}
+
+	category, err := s.queries.GetCategory(ctx, db.GetCategoryParams{ID: cid, UserID: uid})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Category{}, ErrCategoryNotFound
+		}
+		return db.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) Create(ctx context.Context, userID string, input CategoryInput) (db.Category, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Category{}, ErrForbidden
+	}
+
+	parent, err := s.parent(ctx, s.queries, uid, input.ParentID)
+	if err != nil {
+		return db.Category{}, err
+	}
+
+	category, err := s.queries.CreateCategory(ctx, db.CreateCategoryParams{
+		UserID:   uid,
+		ParentID: parent,
+		Name:     strings.TrimSpace(input.Name),
+	})
+	return category, categoryWriteError(err)
+}
+
+// Update renames and/or moves a category. Moves are serialised per
+// user, so two concurrent moves can't each pass the cycle check and
+// together form a loop.
+func (s *CategoryService) Update(ctx context.Context, userID, categoryID string, input CategoryInput) (db.Category, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Category{}, ErrCategoryNotFound
+	}
+	cid, err := uuid.Parse(categoryID)
+	if err != nil {
+		return db.Category{}, ErrCategoryNotFound
+	}
+
+	var category db.Category
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		if err := q.LockUserCategories(ctx, uid); err != nil {
+			return err
+		}
+
+		if _, err := q.GetCategory(ctx, db.GetCategoryParams{ID: cid, UserID: uid}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+
+		parent, err := s.parent(ctx, q, uid, input.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.Valid {
+			cycle, err := q.IsCategoryInSubtree(ctx, db.IsCategoryInSubtreeParams{
+				RootID:     cid,
+				CategoryID: parent.UUID,
+			})
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return ErrCategoryCycle
+			}
+		}
+
+		category, err = q.UpdateCategory(ctx, db.UpdateCategoryParams{
+			ID:       cid,
+			UserID:   uid,
+			Name:     strings.TrimSpace(input.Name),
+			ParentID: parent,
+		})
+		return categoryWriteError(err)
+	})
+	return category, err
+}
+
+// Delete removes a category and unlinks its products. Categories with
+// subcategories have to be emptied first.
+func (s *CategoryService) Delete(ctx context.Context, userID, categoryID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrCategoryNotFound
+	}
+	cid, err := uuid.Parse(categoryID)
+	if err != nil {
+		return ErrCategoryNotFound
+	}
+
+	deleted, err := s.queries.DeleteCategory(ctx, db.DeleteCategoryParams{ID: cid, UserID: uid})
+	if err != nil {
+		if pgErrorCode(err) == pgForeignKeyViolation {
+			return ErrCategoryHasChildren
+		}
+		return err
+	}
+	if deleted == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// parent resolves a ParentID to one of the user's categories
+func (s *CategoryService) parent(ctx context.Context, q db.Querier, userID uuid.UUID, parentID string) (uuid.NullUUID, error) {
+	if parentID == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	pid, err := uuid.Parse(parentID)
+	if err != nil {
+		return uuid.NullUUID{}, ErrInvalidCategory
+	}
+	if _, err := q.GetCategory(ctx, db.GetCategoryParams{ID: pid, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.NullUUID{}, ErrInvalidCategory
+		}
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: pid, Valid: true}, nil
+}
+
+// categoryWriteError maps constraint violations from an insert or
+// update. A foreign key failure means the parent was deleted meanwhile.
+func categoryWriteError(err error) error {
+	switch pgErrorCode(err) {
+	case pgUniqueViolation:
+		return ErrCategoryExists
+	case pgForeignKeyViolation:
+		return ErrInvalidCategory
+	}
+	return err
+}
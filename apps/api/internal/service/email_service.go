@@ -33,3 +33,37 @@ func (s *EmailService) SendWelcome(toEmail string) error {
 	_, err := s.client.Emails.Send(params)
 	return err
 }
+
+func (s *EmailService) SendVerification(toEmail, link string) error {
+	params := &resend.SendEmailRequest{
+		From:    s.fromEmail,
+		To:      []string{toEmail},
+		Subject: "Verify your email address",
+		Html: fmt.Sprintf(`
+			<h1>Confirm your email</h1>
+			<p>Click the link below to verify %s:</p>
+			<p><a href="%s">Verify email</a></p>
+			<p>If you didn't create an account, you can ignore this email.</p>
+		`, toEmail, link),
+	}
+
+	_, err := s.client.Emails.Send(params)
+	return err
+}
+
+func (s *EmailService) SendPasswordReset(toEmail, link string) error {
+	params := &resend.SendEmailRequest{
+		From:    s.fromEmail,
+		To:      []string{toEmail},
+		Subject: "Reset your password",
+		Html: fmt.Sprintf(`
+			<h1>Password reset</h1>
+			<p>Someone asked to reset the password for %s.</p>
+			<p><a href="%s">Choose a new password</a></p>
+			<p>The link expires soon and works once. If this wasn't you, you can ignore this email.</p>
+		`, toEmail, link),
+	}
+
+	_, err := s.client.Emails.Send(params)
+	return err
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/google/uuid"
+)
+
+// order statuses
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+)
+
+// orderTransitions lists the statuses each status may move to.
+// delivered and cancelled are final.
+var orderTransitions = map[string][]string{
+	OrderPending: {OrderPaid, OrderCancelled},
+	OrderPaid:    {OrderShipped, OrderCancelled},
+	OrderShipped: {OrderDelivered},
+}
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrEmptyOrder    = errors.New("order has no items")
+	// ErrOrderTransition is a status change the state machine doesn't
+	// allow from the order's current status
+	ErrOrderTransition = errors.New("order cannot move to that status")
+	// ErrProductUnavailable is an order line naming a product that
+	// doesn't exist, is in the trash or isn't for sale to the buyer
+	ErrProductUnavailable = errors.New("product is not available")
+)
+
+// forSale reports whether buyer can see the product outside its
+// owner's routes: put it in a cart, order it, review it. Anyone can
+// once it's published; its owner always can. Anonymous shoppers are
+// uuid.Nil.
+func forSale(p db.Product, buyer uuid.UUID) bool {
+	return p.IsPublished || p.UserID == buyer
+}
+
+// OrderItemError ties a checkout failure to the order line that caused it
+type OrderItemError struct {
+	// Index is the line's position in the request
+	Index int
+	Err   error
+}
+
+func (e *OrderItemError) Error() string { return e.Err.Error() }
+
+func (e *OrderItemError) Unwrap() error { return e.Err }
+
+type OrderService struct {
+	database *sql.DB
+	queries  db.Querier
+}
+
+func NewOrderService(database *sql.DB, queries db.Querier) *OrderService {
+	return &OrderService{
+		database: database,
+		queries:  queries,
+	}
+}
+
+type OrderItemInput struct {
+	ProductID string
+	Quantity  int32
+}
+
+// Order is an order together with its lines
+type Order struct {
+	db.Order
+	Items []db.OrderItem `json:"items"`
+}
+
+type OrderPage struct {
+	Orders []db.Order
+	Total  int64
+}
+
+// ValidOrderStatus reports whether status is one an order can be in
+func ValidOrderStatus(status string) bool {
+	switch status {
+	case OrderPending, OrderPaid, OrderShipped, OrderDelivered, OrderCancelled:
+		return true
+	}
+	return false
+}
+
+// orderLine is one product in an order or basket. The same product
+// twice becomes one line; errors point at its first appearance.
+type orderLine struct {
+	productID uuid.UUID
+	quantity  int32
+	index     int
+	product   db.Product
+}
+
+// orderLines merges items into lines, sorted by product ID
+func orderLines(items []OrderItemInput) ([]*orderLine, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyOrder
+	}
+
+	var lines []*orderLine
+	byID := make(map[uuid.UUID]*orderLine, len(items))
+	for i, item := range items {
+		pid, err := uuid.Parse(item.ProductID)
+		if err != nil {
+			return nil, &OrderItemError{Index: i, Err: ErrProductUnavailable}
+		}
+		if l, ok := byID[pid]; ok {
+			l.quantity += item.Quantity
+			continue
+		}
+		l := &orderLine{productID: pid, quantity: item.Quantity, index: i}
+		byID[pid] = l
+		lines = append(lines, l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return bytes.Compare(lines[i].productID[:], lines[j].productID[:]) < 0
+	})
+	return lines, nil
+}
+
+// basketOf prices lines whose products have been loaded. The first
+// line in the request sets the currency; every other must match it.
+func basketOf(lines []*orderLine) (basket, error) {
+	first := lines[0]
+	for _, l := range lines {
+		if l.index < first.index {
+			first = l
+		}
+	}
+
+	b := basket{currency: first.product.Currency}
+	for _, l := range lines {
+		if l.product.Currency != b.currency {
+			return basket{}, &OrderItemError{Index: l.index, Err: money.ErrCurrencyMismatch}
+		}
+		amount := l.product.Price.MulInt(int64(l.quantity))
+		b.lines = append(b.lines, basketLine{productID: l.productID, amount: amount})
+		b.subtotal = b.subtotal.Add(amount)
+	}
+	return b, nil
+}
+
+// Create places an order for the buyer in scope. In one transaction it
+// locks every product, takes the ordered quantity out of stock (with a
+// sale in the ledger) and copies each product's name and current price
+// onto the order. Products are locked in ID order so two checkouts
+// sharing products can't deadlock. Any published product can be
+// ordered, as long as it isn't in the trash, and so can the buyer's
+// own unpublished ones. Every product must be
+// priced in the same currency, which becomes the order's.
+//
+// Promotions that apply by themselves are taken off the total, along
+// with the coupon behind couponCode if there is one. A coupon that
+// can't be used fails the order with a *CouponError.
+func (s *OrderService) Create(ctx context.Context, scope Scope, items []OrderItemInput, couponCode string) (Order, error) {
+	buyer, err := uuid.Parse(scope.UserID)
+	if err != nil {
+		return Order{}, ErrForbidden
+	}
+	lines, err := orderLines(items)
+	if err != nil {
+		return Order{}, err
+	}
+
+	var order Order
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		for _, l := range lines {
+			product, err := q.GetProductForUpdate(ctx, db.GetProductForUpdateParams{ID: l.productID})
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return &OrderItemError{Index: l.index, Err: ErrProductUnavailable}
+				}
+				return err
+			}
+			if !forSale(product, buyer) {
+				return &OrderItemError{Index: l.index, Err: ErrProductUnavailable}
+			}
+			if product.Stock < l.quantity {
+				return &OrderItemError{Index: l.index, Err: ErrInsufficientStock}
+			}
+			l.product = product
+		}
+		b, err := basketOf(lines)
+		if err != nil {
+			return err
+		}
+
+		eval, err := applyPromotions(ctx, q, buyer, b, couponCode, true)
+		if err != nil {
+			return err
+		}
+		if eval.CouponRejection != "" {
+			return &CouponError{Reason: eval.CouponRejection}
+		}
+
+		created, err := q.CreateOrder(ctx, db.CreateOrderParams{UserID: buyer, Currency: b.currency})
+		if err != nil {
+			return err
+		}
+		note := "order " + created.ID.String()
+
+		for _, l := range lines {
+			product, err := q.AdjustProductStock(ctx, db.AdjustProductStockParams{
+				Delta: -l.quantity,
+				ID:    l.productID,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := recordStockMovement(ctx, q, scope, product, -l.quantity, StockSale, note); err != nil {
+				return err
+			}
+
+			if _, err := q.CreateOrderItem(ctx, db.CreateOrderItemParams{
+				OrderID:     created.ID,
+				ProductID:   uuid.NullUUID{UUID: product.ID, Valid: true},
+				ProductName: product.Name,
+				UnitPrice:   product.Price,
+				Quantity:    l.quantity,
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, d := range eval.Discounts {
+			if err := q.CreatePromotionRedemption(ctx, db.CreatePromotionRedemptionParams{
+				PromotionID: d.PromotionID,
+				UserID:      buyer,
+				OrderID:     created.ID,
+				Amount:      d.Amount.Amount,
+			}); err != nil {
+				return err
+			}
+		}
+
+		order.Order, err = q.SetOrderTotal(ctx, db.SetOrderTotalParams{
+			ID:       created.ID,
+			Discount: eval.Discount.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		order.Items, err = q.ListOrderItems(ctx, created.ID)
+		return err
+	})
+	return order, err
+}
+
+func (s *OrderService) Get(ctx context.Context, scope Scope, orderID string) (Order, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return Order{}, ErrOrderNotFound
+	}
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return Order{}, ErrOrderNotFound
+	}
+
+	o, err := s.queries.GetOrder(ctx, db.GetOrderParams{ID: oid, UserID: owner})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Order{}, ErrOrderNotFound
+		}
+		return Order{}, err
+	}
+
+	items, err := s.queries.ListOrderItems(ctx, o.ID)
+	if err != nil {
+		return Order{}, err
+	}
+
+	return Order{Order: o, Items: items}, nil
+}
+
+// List returns orders newest first, optionally only those in status
+func (s *OrderService) List(ctx context.Context, scope Scope, status string, page Page) (OrderPage, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	filter := sql.NullString{String: status, Valid: status != ""}
+
+	orders, err := s.queries.ListOrders(ctx, db.ListOrdersParams{
+		UserID: owner,
+		Status: filter,
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	total, err := s.queries.CountOrders(ctx, db.CountOrdersParams{
+		UserID: owner,
+		Status: filter,
+	})
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	return OrderPage{Orders: orders, Total: total}, nil
+}
+
+// Transition moves the order to status if the state machine allows it.
+// Cancelling puts every line's quantity back into stock, trashed
+// products included; lines whose product has been purged are skipped.
+// It also gives back the promotion uses the order took.
+func (s *OrderService) Transition(ctx context.Context, scope Scope, orderID, status string) (Order, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return Order{}, ErrOrderNotFound
+	}
+	oid, err := uuid.Parse(orderID)
+	if err != nil {
+		return Order{}, ErrOrderNotFound
+	}
+
+	var order Order
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		current, err := q.GetOrderForUpdate(ctx, db.GetOrderForUpdateParams{ID: oid, UserID: owner})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if !canTransition(current.Status, status) {
+			return ErrOrderTransition
+		}
+
+		order.Order, err = q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+			Status:     status,
+			ID:         current.ID,
+			FromStatus: current.Status,
+		})
+		if err != nil {
+			return err
+		}
+		order.Items, err = q.ListOrderItems(ctx, current.ID)
+		if err != nil {
+			return err
+		}
+
+		if status != OrderCancelled {
+			return nil
+		}
+		if err := q.DeleteOrderRedemptions(ctx, current.ID); err != nil {
+			return err
+		}
+		note := "order " + current.ID.String() + " cancelled"
+		for _, item := range order.Items {
+			if !item.ProductID.Valid {
+				continue
+			}
+			product, err := q.ReleaseProductStock(ctx, db.ReleaseProductStockParams{
+				Quantity: item.Quantity,
+				ID:       item.ProductID.UUID,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := recordStockMovement(ctx, q, scope, product, item.Quantity, StockReturn, note); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return order, err
+}
+
+// Cancel is Transition to cancelled
+func (s *OrderService) Cancel(ctx context.Context, scope Scope, orderID string) (Order, error) {
+	return s.Transition(ctx, scope, orderID, OrderCancelled)
+}
+
+func canTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package service
+
+import "math"
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	// MaxOffset is the furthest a page may start: queries take their
+	// OFFSET as an int32
+	MaxOffset = math.MaxInt32
+)
+
+// Page is a 1-based offset page request
+type Page struct {
+	Number int
+	Size   int
+}
+
+func (p Page) Limit() int32 {
+	return int32(p.Size)
+}
+
+// Offset fits in an int32 because pages starting past MaxOffset are
+// rejected when they're parsed
+func (p Page) Offset() int32 {
+	return int32((p.Number - 1) * p.Size)
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+)
+
+// An export's transaction is cut off if a single FETCH runs longer
+// than exportStatementTimeout, or fn keeps it waiting between two for
+// longer than exportIdleTimeout, e.g. because the client stopped reading.
+const (
+	exportStatementTimeout = time.Minute
+	exportIdleTimeout      = 2 * time.Minute
+)
+
+// Export calls fn for every product matching the listing filters, in
+// listing order. Limit and Cursor are ignored. Rows come from a
+// Postgres cursor inside a transaction that stays open until fn has
+// seen the last one, so fn should hand rows off rather than collect them.
+func (s *ProductService) Export(ctx context.Context, scope Scope, input ListProductsInput, fn func(db.Product) error) error {
+	owner, err := scope.owner()
+	if err != nil {
+		return err
+	}
+
+	if input.Sort == "" {
+		input.Sort = "created_at"
+	}
+
+	return withTx(ctx, s.database, func(q *db.Queries) error {
+		return q.ExportProducts(ctx, db.ExportProductsParams{
+			Filter: input.filter(owner),
+			Sort:   input.Sort,
+			Desc:   input.Desc,
+
+			StatementTimeout: exportStatementTimeout,
+			IdleTimeout:      exportIdleTimeout,
+		}, fn)
+	})
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/imaging"
+	"github.com/falasefemi2/goreact-boilerplate/internal/storage"
+	"github.com/falasefemi2/goreact-boilerplate/internal/urlsign"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrImageNotFound    = errors.New("image not found")
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrImageDimensions  = errors.New("image dimensions are too large")
+	ErrTooManyImages    = errors.New("product has too many images")
+	ErrImageLinkInvalid = errors.New("image link is invalid or has expired")
+)
+
+// imageTypes are the formats we can decode, keyed by the content type
+// http.DetectContentType sniffs from their first bytes
+var imageTypes = map[string]struct {
+	format    string
+	extension string
+}{
+	"image/jpeg": {"jpeg", "jpg"},
+	"image/png":  {"png", "png"},
+	"image/gif":  {"gif", "gif"},
+}
+
+type ImageConfig struct {
+	MaxBytes      int64
+	MaxPixels     int
+	ThumbnailSize int
+	MaxPerProduct int
+	// BaseURL is where the image route is mounted; signed links are
+	// BaseURL + "/" + blob key
+	BaseURL string
+}
+
+// ImageService stores product images and their thumbnails in a
+// BlobStore and hands out signed links to them
+type ImageService struct {
+	queries db.Querier
+	blobs   storage.BlobStore
+	signer  *urlsign.Signer
+	cfg     ImageConfig
+}
+
+func NewImageService(queries db.Querier, blobs storage.BlobStore, signer *urlsign.Signer, cfg ImageConfig) *ImageService {
+	return &ImageService{
+		queries: queries,
+		blobs:   blobs,
+		signer:  signer,
+		cfg:     cfg,
+	}
+}
+
+// ProductImage is an image with links to it and its thumbnail. The
+// links stop working at URLExpiresAt.
+type ProductImage struct {
+	db.ProductImage
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+}
+
+func (s *ImageService) MaxBytes() int64 {
+	return s.cfg.MaxBytes
+}
+
+// Upload stores an image for the product. The type is sniffed from the
+// bytes themselves and must decode as that type; whatever the client
+// claimed it was is ignored.
+func (s *ImageService) Upload(ctx context.Context, scope Scope, productID string, body io.Reader) (ProductImage, error) {
+	product, err := s.product(ctx, scope, productID)
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	count, err := s.queries.CountProductImages(ctx, product.ID)
+	if err != nil {
+		return ProductImage{}, err
+	}
+	if count >= int64(s.cfg.MaxPerProduct) {
+		return ProductImage{}, ErrTooManyImages
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, s.cfg.MaxBytes+1))
+	if err != nil {
+		return ProductImage{}, err
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return ProductImage{}, ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	kind, ok := imageTypes[contentType]
+	if !ok {
+		return ProductImage{}, ErrUnsupportedImage
+	}
+
+	// check the dimensions before decoding allocates room for them
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != kind.format {
+		return ProductImage{}, ErrUnsupportedImage
+	}
+	if config.Width*config.Height > s.cfg.MaxPixels {
+		return ProductImage{}, ErrImageDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ProductImage{}, ErrUnsupportedImage
+	}
+
+	thumb, thumbType, thumbExt, err := encodeThumbnail(imaging.Thumbnail(img, s.cfg.ThumbnailSize), kind.format)
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	id := uuid.New()
+	prefix := fmt.Sprintf("products/%s/%s", product.ID, id)
+	params := db.CreateProductImageParams{
+		ID:           id,
+		ProductID:    product.ID,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Width:        int32(config.Width),
+		Height:       int32(config.Height),
+		StorageKey:   prefix + "." + kind.extension,
+		ThumbnailKey: prefix + "-thumb." + thumbExt,
+	}
+
+	if err := s.blobs.Put(ctx, params.StorageKey, bytes.NewReader(data), contentType); err != nil {
+		return ProductImage{}, err
+	}
+	if err := s.blobs.Put(ctx, params.ThumbnailKey, bytes.NewReader(thumb), thumbType); err != nil {
+		s.discard(params.StorageKey)
+		return ProductImage{}, err
+	}
+
+	row, err := s.queries.CreateProductImage(ctx, params)
+	if err != nil {
+		s.discard(params.StorageKey, params.ThumbnailKey)
+		if pgErrorCode(err) == pgForeignKeyViolation {
+			// the product was purged while we were uploading
+			return ProductImage{}, ErrProductNotFound
+		}
+		return ProductImage{}, err
+	}
+
+	return s.withURLs(row), nil
+}
+
+func (s *ImageService) List(ctx context.Context, scope Scope, productID string) ([]ProductImage, error) {
+	product, err := s.product(ctx, scope, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.queries.ListProductImages(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]ProductImage, 0, len(rows))
+	for _, row := range rows {
+		images = append(images, s.withURLs(row))
+	}
+	return images, nil
+}
+
+// Delete removes the image. Its blobs go shortly after, via the
+// cleanup job.
+func (s *ImageService) Delete(ctx context.Context, scope Scope, productID, imageID string) error {
+	product, err := s.product(ctx, scope, productID)
+	if err != nil {
+		return err
+	}
+	iid, err := uuid.Parse(imageID)
+	if err != nil {
+		return ErrImageNotFound
+	}
+
+	deleted, err := s.queries.DeleteProductImage(ctx, db.DeleteProductImageParams{
+		ID:        iid,
+		ProductID: product.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrImageNotFound
+	}
+
+	return nil
+}
+
+// Open checks a signed link and returns the blob it points at, along
+// with when the link expires. The caller must close the blob's body.
+func (s *ImageService) Open(ctx context.Context, key string, query url.Values) (*storage.Blob, time.Time, error) {
+	expires, err := s.signer.Verify(key, query)
+	if err != nil {
+		return nil, time.Time{}, ErrImageLinkInvalid
+	}
+
+	blob, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, time.Time{}, ErrImageNotFound
+		}
+		return nil, time.Time{}, err
+	}
+
+	return blob, expires, nil
+}
+
+func (s *ImageService) product(ctx context.Context, scope Scope, productID string) (db.Product, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
+
+	product, err := s.queries.GetProductByID(ctx, db.GetProductByIDParams{
+		ID:     pid,
+		UserID: owner,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Product{}, ErrProductNotFound
+		}
+		return db.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (s *ImageService) withURLs(row db.ProductImage) ProductImage {
+	out := ProductImage{ProductImage: row}
+	out.URL, out.URLExpiresAt = s.url(row.StorageKey)
+	out.ThumbnailURL, _ = s.url(row.ThumbnailKey)
+	return out
+}
+
+func (s *ImageService) url(key string) (string, time.Time) {
+	query, expires := s.signer.Sign(key)
+	return s.cfg.BaseURL + "/" + key + "?" + query.Encode(), expires
+}
+
+// discard deletes blobs of an upload that didn't make it into the
+// database. Failures only leak a file, so they're logged, not returned.
+func (s *ImageService) discard(keys ...string) {
+	ctx := context.Background()
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			slog.Warn("could not delete orphaned blob", "key", key, "error", err)
+		}
+	}
+}
+
+// DeleteBlobs is a Job that works through the blob_deletions queue,
+// where removed images' blobs wait, every minute. A blob that fails
+// to delete stays queued and is retried next time.
+func (s *ImageService) DeleteBlobs(ctx context.Context) {
+	const batchSize = 100
+
+	background.Every(ctx, time.Minute, func(ctx context.Context) {
+		keys, err := s.queries.ListBlobDeletions(ctx, batchSize)
+		if err != nil {
+			slog.Error("failed to list blobs to delete", "error", err)
+			return
+		}
+
+		for _, key := range keys {
+			if err := s.blobs.Delete(ctx, key); err != nil {
+				slog.Error("failed to delete blob", "key", key, "error", err)
+				continue
+			}
+			if err := s.queries.DeleteBlobDeletion(ctx, key); err != nil {
+				slog.Error("failed to dequeue deleted blob", "key", key, "error", err)
+			}
+		}
+	})
+}
+
+// encodeThumbnail writes JPEG thumbnails for JPEG sources and PNG for
+// everything else, so transparency survives
+func encodeThumbnail(img image.Image, sourceFormat string) (data []byte, contentType, extension string, err error) {
+	var buf bytes.Buffer
+	if sourceFormat == "jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", "jpg", err
+	}
+
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", "png", err
+}
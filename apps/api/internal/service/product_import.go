@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+var ErrImportNotFound = errors.New("import not found")
+
+// Import modes. Atomic imports insert every row or none; best-effort
+// imports insert what they can and report the rest.
+const (
+	ImportAtomic     = "atomic"
+	ImportBestEffort = "best_effort"
+)
+
+// Import statuses
+const (
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+const (
+	// rows per transaction in best-effort mode; a batch that fails is
+	// retried row by row to find the culprits
+	importBatchSize = 500
+	// a report with more errors than this is truncated; the failed
+	// count stays exact
+	maxImportErrors = 1000
+	// background imports are cancelled after this long, so one still
+	// running past it was lost with its process
+	importTimeout = time.Hour
+)
+
+// ImportRow is one parsed, validated product, with the line it came
+// from for error reporting
+type ImportRow struct {
+	Line    int
+	Product CreateProductInput
+}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	Status   string           `json:"status"`
+	Total    int              `json:"total_rows"`
+	Inserted int              `json:"inserted_rows"`
+	Failed   int              `json:"failed_rows"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+func (r *ImportReport) fail(err ImportRowError) {
+	r.Failed++
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, err)
+	}
+}
+
+// Import inserts rows for the caller and waits for the result. invalid
+// holds rows that already failed parsing or validation; in atomic mode
+// any of them means nothing is inserted.
+func (s *ProductService) Import(ctx context.Context, scope Scope, mode string, rows []ImportRow, invalid []ImportRowError) (ImportReport, error) {
+	uid, err := uuid.Parse(scope.UserID)
+	if err != nil {
+		return ImportReport{}, ErrForbidden
+	}
+
+	return s.runImport(ctx, uid, mode, rows, invalid, func(ImportReport) {}), nil
+}
+
+// StartImport records an import job and hands it to RunImports to run
+// in the background. Poll it with GetImport.
+func (s *ProductService) StartImport(ctx context.Context, scope Scope, mode string, rows []ImportRow, invalid []ImportRowError) (db.ProductImport, error) {
+	uid, err := uuid.Parse(scope.UserID)
+	if err != nil {
+		return db.ProductImport{}, ErrForbidden
+	}
+
+	job, err := s.queries.CreateProductImport(ctx, db.CreateProductImportParams{
+		UserID:    uid,
+		Mode:      mode,
+		TotalRows: int32(len(rows) + len(invalid)),
+	})
+	if err != nil {
+		return db.ProductImport{}, err
+	}
+
+	// the job outlives the request that started it; RunImports gives
+	// it a context of its own
+	run := func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, importTimeout)
+		defer cancel()
+
+		report := s.runImport(ctx, uid, mode, rows, invalid, func(progress ImportReport) {
+			if err := s.queries.UpdateProductImportProgress(ctx, db.UpdateProductImportProgressParams{
+				ID:           job.ID,
+				InsertedRows: int32(progress.Inserted),
+				FailedRows:   int32(progress.Failed),
+			}); err != nil {
+				slog.Error("failed to record import progress", "import_id", job.ID, "error", err)
+			}
+		})
+		if ctx.Err() != nil {
+			report.Status = ImportFailed
+			report.Errors = append(report.Errors, ImportRowError{Message: "import was interrupted"})
+		}
+
+		// record how it ended even if it was cancelled
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+
+		errs, err := json.Marshal(report.Errors)
+		if err != nil {
+			errs = []byte("[]")
+		}
+		if err := s.queries.FinishProductImport(ctx, db.FinishProductImportParams{
+			ID:           job.ID,
+			Status:       report.Status,
+			InsertedRows: int32(report.Inserted),
+			FailedRows:   int32(report.Failed),
+			Errors:       errs,
+		}); err != nil {
+			slog.Error("failed to record import result", "import_id", job.ID, "error", err)
+		}
+	}
+
+	select {
+	case s.imports <- run:
+		return job, nil
+	case <-ctx.Done():
+		// FailStaleImports closes the job once it's past importTimeout
+		return db.ProductImport{}, ctx.Err()
+	}
+}
+
+// RunImports is a Job that runs the imports StartImport hands it, each
+// in its own goroutine. Once ctx is cancelled it takes no more and
+// waits for the running ones, which are cancelled with it and recorded
+// as failed.
+func (s *ProductService) RunImports(ctx context.Context) {
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case run := <-s.imports:
+			running.Go(func() { run(ctx) })
+		}
+	}
+}
+
+// FailStaleImports is a Job that, every ten minutes, marks failed the
+// imports still running past importTimeout: the process running them
+// stopped before it could record how they ended
+func (s *ProductService) FailStaleImports(ctx context.Context) {
+	background.Every(ctx, 10*time.Minute, func(ctx context.Context) {
+		failed, err := s.queries.FailStaleProductImports(ctx, time.Now().Add(-importTimeout))
+		if err != nil {
+			slog.Error("failed to fail stale imports", "error", err)
+			return
+		}
+		if failed > 0 {
+			slog.Warn("failed imports that were interrupted", "count", failed)
+		}
+	})
+}
+
+func (s *ProductService) GetImport(ctx context.Context, scope Scope, importID string) (db.ProductImport, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return db.ProductImport{}, ErrImportNotFound
+	}
+	id, err := uuid.Parse(importID)
+	if err != nil {
+		return db.ProductImport{}, ErrImportNotFound
+	}
+
+	job, err := s.queries.GetProductImport(ctx, db.GetProductImportParams{
+		ID:     id,
+		UserID: owner,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ProductImport{}, ErrImportNotFound
+		}
+		return db.ProductImport{}, err
+	}
+
+	return job, nil
+}
+
+// runImport does the inserting. progress is called after every
+// committed batch.
+func (s *ProductService) runImport(
+	ctx context.Context,
+	userID uuid.UUID,
+	mode string,
+	rows []ImportRow,
+	invalid []ImportRowError,
+	progress func(ImportReport),
+) ImportReport {
+	report := ImportReport{
+		Status: ImportCompleted,
+		Total:  len(rows) + len(invalid),
+		Errors: []ImportRowError{},
+	}
+	for _, e := range invalid {
+		report.fail(e)
+	}
+
+	if mode == ImportAtomic {
+		if len(invalid) > 0 {
+			report.Status = ImportFailed
+			return report
+		}
+
+		var failed *ImportRow
+		err := withTx(ctx, s.database, func(q *db.Queries) error {
+			for i := range rows {
+				if _, err := q.CreateProduct(ctx, createProductParams(userID, rows[i].Product)); err != nil {
+					failed = &rows[i]
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Error("atomic product import failed", "error", err)
+			report.Status = ImportFailed
+			report.Failed = report.Total
+			line := 0
+			if failed != nil {
+				line = failed.Line
+			}
+			report.Errors = append(report.Errors, ImportRowError{
+				Line:    line,
+				Message: "could not insert product; nothing was imported",
+			})
+			return report
+		}
+
+		report.Inserted = len(rows)
+		return report
+	}
+
+	for start := 0; start < len(rows); start += importBatchSize {
+		if ctx.Err() != nil {
+			break
+		}
+		batch := rows[start:min(start+importBatchSize, len(rows))]
+
+		err := withTx(ctx, s.database, func(q *db.Queries) error {
+			for _, row := range batch {
+				if _, err := q.CreateProduct(ctx, createProductParams(userID, row.Product)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			report.Inserted += len(batch)
+		} else if ctx.Err() == nil {
+			// something in the batch is bad; go row by row to find out what
+			for _, row := range batch {
+				if _, err := s.queries.CreateProduct(ctx, createProductParams(userID, row.Product)); err != nil {
+					report.fail(ImportRowError{Line: row.Line, Message: "could not insert product"})
+					continue
+				}
+				report.Inserted++
+			}
+		}
+
+		progress(report)
+	}
+
+	if report.Inserted == 0 && report.Total > 0 {
+		report.Status = ImportFailed
+	}
+	return report
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/google/uuid"
+)
+
+// price history reasons
+const (
+	PriceInitial   = "initial"
+	PriceUpdate    = "update"
+	PriceScheduled = "scheduled"
+)
+
+// scheduled price change statuses
+const (
+	PriceChangePending = "pending"
+	PriceChangeApplied = "applied"
+	// the product changed currency before the change was due
+	PriceChangeSkipped = "skipped"
+)
+
+// priceScheduleInterval is how often the scheduler looks for changes
+// that have fallen due, so at most how late one is applied
+const priceScheduleInterval = time.Minute
+
+var (
+	ErrPriceChangeNotFound = errors.New("scheduled price change not found")
+	ErrPriceChangeInPast   = errors.New("scheduled price change must be in the future")
+)
+
+type PriceHistoryPage struct {
+	Entries []db.PriceHistory
+	Total   int64
+}
+
+type SchedulePriceChangeInput struct {
+	Price       money.Decimal
+	EffectiveAt time.Time
+}
+
+func (s *ProductService) PriceHistory(ctx context.Context, scope Scope, productID string, page Page) (PriceHistoryPage, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return PriceHistoryPage{}, err
+	}
+
+	entries, err := s.queries.ListPriceHistory(ctx, db.ListPriceHistoryParams{
+		ProductID: product.ID,
+		Limit:     page.Limit(),
+		Offset:    page.Offset(),
+	})
+	if err != nil {
+		return PriceHistoryPage{}, err
+	}
+
+	total, err := s.queries.CountPriceHistory(ctx, product.ID)
+	if err != nil {
+		return PriceHistoryPage{}, err
+	}
+
+	return PriceHistoryPage{Entries: entries, Total: total}, nil
+}
+
+// ScheduledPriceChanges lists the product's scheduled changes, past and
+// pending, in the order they take effect
+func (s *ProductService) ScheduledPriceChanges(ctx context.Context, scope Scope, productID string) ([]db.ScheduledPriceChange, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queries.ListScheduledPriceChanges(ctx, product.ID)
+}
+
+// SchedulePriceChange queues a new price for the product at a future
+// time. It's in the product's current currency and skipped if the
+// product is in another one by then. A sale is two changes: the sale
+// price at its start and the regular price at its end.
+func (s *ProductService) SchedulePriceChange(ctx context.Context, scope Scope, productID string, input SchedulePriceChangeInput) (db.ScheduledPriceChange, error) {
+	if !input.EffectiveAt.After(time.Now()) {
+		return db.ScheduledPriceChange{}, ErrPriceChangeInPast
+	}
+
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return db.ScheduledPriceChange{}, err
+	}
+	if err := CheckPrice(input.Price, product.Currency); err != nil {
+		return db.ScheduledPriceChange{}, err
+	}
+
+	return s.queries.CreateScheduledPriceChange(ctx, db.CreateScheduledPriceChangeParams{
+		ProductID:   product.ID,
+		UserID:      scopeActor(scope),
+		Price:       input.Price,
+		Currency:    product.Currency,
+		EffectiveAt: input.EffectiveAt,
+	})
+}
+
+// CancelPriceChange calls off a change that hasn't been applied yet
+func (s *ProductService) CancelPriceChange(ctx context.Context, scope Scope, productID, changeID string) error {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return err
+	}
+	cid, err := uuid.Parse(changeID)
+	if err != nil {
+		return ErrPriceChangeNotFound
+	}
+
+	deleted, err := s.queries.DeleteScheduledPriceChange(ctx, db.DeleteScheduledPriceChangeParams{
+		ID:        cid,
+		ProductID: product.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrPriceChangeNotFound
+	}
+
+	return nil
+}
+
+// ApplyScheduledPrices is a Job that applies due price changes every
+// priceScheduleInterval, oldest first, each in its own transaction
+func (s *ProductService) ApplyScheduledPrices(ctx context.Context) {
+	background.Every(ctx, priceScheduleInterval, func(ctx context.Context) {
+		applied := 0
+		for {
+			ok, err := s.applyNextPriceChange(ctx)
+			if err != nil {
+				slog.Error("failed to apply scheduled price change", "error", err)
+				break
+			}
+			if !ok {
+				break
+			}
+			applied++
+		}
+		if applied > 0 {
+			slog.Info("applied scheduled price changes", "count", applied)
+		}
+	})
+}
+
+// applyNextPriceChange applies the oldest due change and reports
+// whether there was one. The change is marked in the same transaction
+// as the price is set, so a crash either does both or neither and
+// nothing is applied twice.
+func (s *ProductService) applyNextPriceChange(ctx context.Context) (bool, error) {
+	found := false
+	err := withTx(ctx, s.database, func(q *db.Queries) error {
+		change, err := q.NextDueScheduledPriceChange(ctx)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		found = true
+
+		product, err := q.SetScheduledProductPrice(ctx, db.SetScheduledProductPriceParams{
+			Price:    change.Price,
+			ID:       change.ProductID,
+			Currency: change.Currency,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("skipping scheduled price change: product changed currency", "change_id", change.ID, "product_id", change.ProductID)
+			return q.MarkScheduledPriceChange(ctx, db.MarkScheduledPriceChangeParams{
+				ID:     change.ID,
+				Status: PriceChangeSkipped,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.CreatePriceHistoryEntry(ctx, db.CreatePriceHistoryEntryParams{
+			ProductID:         product.ID,
+			UserID:            change.UserID,
+			Price:             product.Price,
+			Currency:          product.Currency,
+			Reason:            PriceScheduled,
+			ScheduledChangeID: uuid.NullUUID{UUID: change.ID, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		return q.MarkScheduledPriceChange(ctx, db.MarkScheduledPriceChangeParams{
+			ID:     change.ID,
+			Status: PriceChangeApplied,
+		})
+	})
+	return found, err
+}
+
+// recordPriceChange appends a history entry if product's price or
+// currency differs from before's
+func recordPriceChange(ctx context.Context, q *db.Queries, scope Scope, before, product db.Product) error {
+	if product.Price.Equal(before.Price) && product.Currency == before.Currency {
+		return nil
+	}
+
+	_, err := q.CreatePriceHistoryEntry(ctx, db.CreatePriceHistoryEntryParams{
+		ProductID: product.ID,
+		UserID:    scopeActor(scope),
+		Price:     product.Price,
+		Currency:  product.Currency,
+		Reason:    PriceUpdate,
+	})
+	return err
+}
+
+// scopeActor is the user acting through scope, for audit columns
+func scopeActor(scope Scope) uuid.NullUUID {
+	if uid, err := uuid.Parse(scope.UserID); err == nil {
+		return uuid.NullUUID{UUID: uid, Valid: true}
+	}
+	return uuid.NullUUID{}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+)
+
+var ErrEmptySearchQuery = errors.New("search query has no searchable terms")
+
+type SearchResults struct {
+	Results []db.SearchProductsRow
+	Total   int64
+}
+
+// Search ranks the caller's products against a free-text query.
+// See parseSearchQuery for the syntax.
+func (s *ProductService) Search(ctx context.Context, scope Scope, query string, page Page) (SearchResults, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return SearchResults{}, err
+	}
+
+	tsquery := parseSearchQuery(query)
+	if tsquery == "" {
+		return SearchResults{}, ErrEmptySearchQuery
+	}
+
+	results, err := s.queries.SearchProducts(ctx, db.SearchProductsParams{
+		Query:  tsquery,
+		UserID: owner,
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return SearchResults{}, err
+	}
+
+	total, err := s.queries.CountSearchProducts(ctx, db.CountSearchProductsParams{
+		Query:  tsquery,
+		UserID: owner,
+	})
+	if err != nil {
+		return SearchResults{}, err
+	}
+
+	return SearchResults{Results: results, Total: total}, nil
+}
+
+// parseSearchQuery turns user input into a to_tsquery expression.
+// Every term must match; "quoted words" must appear next to each other
+// and a trailing * matches any word with that prefix:
+//
+//	red "running shoe" leath*  ->  red & running <-> shoe & leath:*
+//
+// Only letters and digits reach Postgres, so no input can make
+// to_tsquery fail. Returns "" when nothing searchable is left.
+func parseSearchQuery(input string) string {
+	var terms []string
+
+	for i, chunk := range strings.Split(input, `"`) {
+		// odd chunks sit between quotes
+		if i%2 == 1 {
+			if phrase := tsPhrase(strings.Fields(chunk)); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(chunk) {
+			if term := tsPhrase([]string{word}); term != "" {
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// tsPhrase joins words with the followed-by operator. Punctuation inside
+// a word splits it the same way to_tsvector does ("t-shirt" -> t <-> shirt).
+func tsPhrase(words []string) string {
+	var lexemes []string
+	for _, word := range words {
+		prefix := strings.HasSuffix(word, "*")
+
+		parts := strings.FieldsFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(parts) == 0 {
+			continue
+		}
+		if prefix {
+			parts[len(parts)-1] += ":*"
+		}
+		lexemes = append(lexemes, parts...)
+	}
+
+	return strings.Join(lexemes, " <-> ")
+}
@@ -3,59 +3,150 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
 	"github.com/google/uuid"
 )
 
 var (
 	ErrProductNotFound = errors.New("product not found")
 	ErrForbidden       = errors.New("forbidden")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrVersionMismatch = errors.New("product has been modified")
+	// ErrInvalidPrice is a negative price, or one finer than its
+	// currency allows, like 100.5 JPY
+	ErrInvalidPrice = errors.New("price is not valid for its currency")
 )
 
 type ProductService struct {
-	queries db.Querier
+	database       *sql.DB
+	queries        db.Store
+	trashRetention time.Duration
+	// StartImport hands background imports to RunImports
+	imports chan func(ctx context.Context)
 }
 
-func NewProductService(queries db.Querier) *ProductService {
-	return &ProductService{queries: queries}
+// NewProductService makes a service whose PurgeTrash job deletes
+// trashed products for good after trashRetention
+func NewProductService(database *sql.DB, queries db.Store, trashRetention time.Duration) *ProductService {
+	return &ProductService{
+		database:       database,
+		queries:        queries,
+		trashRetention: trashRetention,
+		imports:        make(chan func(ctx context.Context)),
+	}
+}
+
+// Scope decides whose products a call may touch. Owner scopes only see
+// the caller's own rows; admin scopes see every user's.
+type Scope struct {
+	UserID   string
+	AllUsers bool
+}
+
+func OwnerScope(userID string) Scope {
+	return Scope{UserID: userID}
+}
+
+// AdminScope still carries the admin's own ID — products an admin
+// creates belong to them
+func AdminScope(userID string) Scope {
+	return Scope{UserID: userID, AllUsers: true}
+}
+
+// owner is the user_id filter for queries; NULL means any owner
+func (s Scope) owner() (uuid.NullUUID, error) {
+	if s.AllUsers {
+		return uuid.NullUUID{}, nil
+	}
+
+	uid, err := uuid.Parse(s.UserID)
+	if err != nil {
+		return uuid.NullUUID{}, ErrForbidden
+	}
+	return uuid.NullUUID{UUID: uid, Valid: true}, nil
 }
 
 type CreateProductInput struct {
 	Name        string
 	Description string
-	Price       string
-	Stock       int32
+	Price       money.Decimal
+	// Currency is an ISO 4217 code; empty means money.DefaultCurrency
+	Currency string
+	Stock    int32
+	// IsPublished lists the product in the public catalog
+	IsPublished bool
 }
 
+// UpdateProductInput is a partial update: nil fields are left as they
+// are. An empty Description clears it.
 type UpdateProductInput struct {
-	Name        string
-	Description string
-	Price       string
-	Stock       int32
+	Name        *string
+	Description *string
+	Price       *money.Decimal
+	Currency    *string
+	Stock       *int32
+	IsPublished *bool
+	// ExpectedVersion, when set, makes the update fail with
+	// ErrVersionMismatch unless the product is still at that version
+	ExpectedVersion *int32
 }
 
-func (s *ProductService) Create(ctx context.Context, userID string, input CreateProductInput) (db.Product, error) {
-	uid, err := uuid.Parse(userID)
+func (s *ProductService) Create(ctx context.Context, scope Scope, input CreateProductInput) (db.Product, error) {
+	uid, err := uuid.Parse(scope.UserID)
 	if err != nil {
 		return db.Product{}, ErrForbidden
 	}
 
-	return s.queries.CreateProduct(ctx, db.CreateProductParams{
-		UserID: uid,
+	params := createProductParams(uid, input)
+	if err := CheckPrice(params.Price, params.Currency); err != nil {
+		return db.Product{}, err
+	}
+
+	return s.queries.CreateProduct(ctx, params)
+}
+
+func createProductParams(userID uuid.UUID, input CreateProductInput) db.CreateProductParams {
+	currency := input.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	return db.CreateProductParams{
+		UserID: userID,
 		Name:   input.Name,
 		Description: sql.NullString{
 			String: input.Description,
 			Valid:  input.Description != "",
 		},
-		Price: input.Price,
-		Stock: input.Stock,
-	})
+		Price:       input.Price,
+		Currency:    currency,
+		Stock:       input.Stock,
+		IsPublished: input.IsPublished,
+	}
+}
+
+// CheckPrice rejects negative prices and prices with more decimal
+// places than the currency has
+func CheckPrice(price money.Decimal, currency string) error {
+	if price.Sign() < 0 || price.MinScale() > money.MinorUnits(currency) {
+		return ErrInvalidPrice
+	}
+	return nil
 }
 
-func (s *ProductService) GetByID(ctx context.Context, userID, productID string) (db.Product, error) {
-	uid, _ := uuid.Parse(userID)
+func (s *ProductService) GetByID(ctx context.Context, scope Scope, productID string) (db.Product, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
 	pid, err := uuid.Parse(productID)
 	if err != nil {
 		return db.Product{}, ErrProductNotFound
@@ -63,7 +154,7 @@ func (s *ProductService) GetByID(ctx context.Context, userID, productID string)
 
 	product, err := s.queries.GetProductByID(ctx, db.GetProductByIDParams{
 		ID:     pid,
-		UserID: uid,
+		UserID: owner,
 	})
 	if err != nil {
 		return db.Product{}, ErrProductNotFound
@@ -72,49 +163,297 @@ func (s *ProductService) GetByID(ctx context.Context, userID, productID string)
 	return product, nil
 }
 
-func (s *ProductService) List(ctx context.Context, userID string) ([]db.Product, error) {
-	uid, err := uuid.Parse(userID)
+// ListProductsInput is a keyset page request. Sort must be one of the
+// whitelisted fields (see db.ValidProductSort); empty means created_at.
+type ListProductsInput struct {
+	Limit      int
+	Cursor     string
+	Sort       string
+	Desc       bool
+	Currency   string
+	MinPrice   string
+	MaxPrice   string
+	MinStock   *int32
+	MaxStock   *int32
+	NamePrefix string
+	// CategoryID also matches the category's descendants
+	CategoryID uuid.NullUUID
+	// Tags match by name, ignoring case: any of them, or all of them
+	// when AllTags is set
+	Tags    []string
+	AllTags bool
+	// IncludeVariants embeds each product's variants in the page
+	IncludeVariants bool
+}
+
+// filter is the db-level filter for the input's listing parameters
+func (input ListProductsInput) filter(owner uuid.NullUUID) db.ProductFilter {
+	tags := NormalizeTagNames(input.Tags)
+	for i, tag := range tags {
+		tags[i] = strings.ToLower(tag)
+	}
+
+	return db.ProductFilter{
+		UserID:     owner,
+		Currency:   input.Currency,
+		MinPrice:   input.MinPrice,
+		MaxPrice:   input.MaxPrice,
+		MinStock:   input.MinStock,
+		MaxStock:   input.MaxStock,
+		NamePrefix: input.NamePrefix,
+		CategoryID: input.CategoryID,
+		Tags:       tags,
+		AllTags:    input.AllTags,
+	}
+}
+
+type ProductPage struct {
+	Products []db.Product
+	// WithVariants holds the same products with their variants, when
+	// the listing asked for them
+	WithVariants []ProductWithVariants
+	// NextCursor is empty on the last page
+	NextCursor string
+}
+
+// productCursor is what an opaque cursor decodes to. It remembers the
+// sort it was issued for, so it can't be replayed against another one.
+type productCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func (s *ProductService) List(ctx context.Context, scope Scope, input ListProductsInput) (ProductPage, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	if input.Sort == "" {
+		input.Sort = "created_at"
+	}
+	if input.Limit <= 0 {
+		input.Limit = DefaultPageSize
+	}
+	input.Limit = min(input.Limit, MaxPageSize)
+
+	var after *db.ProductCursor
+	if input.Cursor != "" {
+		c, err := decodeProductCursor(input.Cursor)
+		if err != nil || c.Sort != input.Sort || c.Desc != input.Desc || !validCursorValue(c.Sort, c.Value) {
+			return ProductPage{}, ErrInvalidCursor
+		}
+		after = &db.ProductCursor{Value: c.Value, ID: c.ID}
+	}
+
+	// fetch one extra row to learn whether there's a next page
+	products, err := s.queries.ListProductsPage(ctx, db.ListProductsPageParams{
+		Filter: input.filter(owner),
+		Sort:   input.Sort,
+		Desc:   input.Desc,
+		After:  after,
+		Limit:  int32(input.Limit + 1),
+	})
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	page := ProductPage{Products: products}
+	if len(products) > input.Limit {
+		page.Products = products[:input.Limit]
+		last := page.Products[input.Limit-1]
+		page.NextCursor, err = encodeProductCursor(productCursor{
+			Sort:  input.Sort,
+			Desc:  input.Desc,
+			Value: db.ProductCursorValue(last, input.Sort),
+			ID:    last.ID,
+		})
+		if err != nil {
+			return ProductPage{}, err
+		}
+	}
+
+	if input.IncludeVariants {
+		page.WithVariants, err = s.withVariants(ctx, page.Products)
+		if err != nil {
+			return ProductPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// validCursorValue checks a decoded cursor value parses as its sort
+// column's type, so a tampered cursor is a 400 rather than a cast error
+func validCursorValue(sort, value string) bool {
+	switch sort {
+	case "created_at", "updated_at":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case "price":
+		_, err := money.Parse(value)
+		return err == nil
+	case "stock":
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+func encodeProductCursor(c productCursor) (string, error) {
+	raw, err := json.Marshal(c)
 	if err != nil {
-		return nil, ErrForbidden
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
 
-	return s.queries.ListProductsByUser(ctx, uid)
+func decodeProductCursor(cursor string) (productCursor, error) {
+	var c productCursor
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(raw, &c)
+	return c, err
 }
 
-func (s *ProductService) Update(ctx context.Context, userID, productID string, input UpdateProductInput) (db.Product, error) {
-	uid, _ := uuid.Parse(userID)
+func (s *ProductService) Update(ctx context.Context, scope Scope, productID string, input UpdateProductInput) (db.Product, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
 	pid, err := uuid.Parse(productID)
 	if err != nil {
 		return db.Product{}, ErrProductNotFound
 	}
 
-	product, err := s.queries.UpdateProduct(ctx, db.UpdateProductParams{
+	params := db.UpdateProductParams{
 		ID:     pid,
-		UserID: uid,
-		Name:   input.Name,
-		Description: sql.NullString{
-			String: input.Description,
-			Valid:  input.Description != "",
-		},
-		Price: input.Price,
-		Stock: input.Stock,
-	})
+		UserID: owner,
+	}
+	if input.Name != nil {
+		params.Name = sql.NullString{String: *input.Name, Valid: true}
+	}
+	if input.Description != nil {
+		params.Description = sql.NullString{String: *input.Description, Valid: *input.Description != ""}
+		params.ClearDescription = *input.Description == ""
+	}
+	if input.Price != nil {
+		params.Price = money.NullDecimal{Decimal: *input.Price, Valid: true}
+	}
+	if input.Currency != nil {
+		params.Currency = sql.NullString{String: *input.Currency, Valid: true}
+	}
+	if input.Stock != nil {
+		params.Stock = sql.NullInt32{Int32: *input.Stock, Valid: true}
+	}
+	if input.IsPublished != nil {
+		params.IsPublished = sql.NullBool{Bool: *input.IsPublished, Valid: true}
+	}
+	if input.ExpectedVersion != nil {
+		params.ExpectedVersion = sql.NullInt32{Int32: *input.ExpectedVersion, Valid: true}
+	}
+
+	var product db.Product
+	if input.Stock == nil && input.Price == nil && input.Currency == nil {
+		product, err = s.queries.UpdateProduct(ctx, params)
+	} else {
+		// stock changes go through the ledger like any other
+		// adjustment, price changes go in the price history, and a new
+		// price or currency has to suit the other one as it will be
+		// after the update
+		err = withTx(ctx, s.database, func(q *db.Queries) error {
+			before, err := q.GetProductForUpdate(ctx, db.GetProductForUpdateParams{
+				ID:     pid,
+				UserID: owner,
+			})
+			if err != nil {
+				return err
+			}
+
+			price, currency := before.Price, before.Currency
+			if input.Price != nil {
+				price = *input.Price
+			}
+			if input.Currency != nil {
+				currency = *input.Currency
+			}
+			if err := CheckPrice(price, currency); err != nil {
+				return err
+			}
+
+			product, err = q.UpdateProduct(ctx, params)
+			if err != nil {
+				return err
+			}
+
+			if err := recordPriceChange(ctx, q, scope, before, product); err != nil {
+				return err
+			}
+			_, err = recordStockMovement(ctx, q, scope, product, product.Stock-before.Stock, StockAdjustment, "set by product update")
+			return err
+		})
+	}
 	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidPrice):
+			return db.Product{}, err
+		case errors.Is(err, sql.ErrNoRows) && input.ExpectedVersion != nil:
+			return db.Product{}, s.missOrMismatch(ctx, pid, owner)
+		}
 		return db.Product{}, ErrProductNotFound
 	}
 
 	return product, nil
 }
 
-func (s *ProductService) Delete(ctx context.Context, userID, productID string) error {
-	uid, _ := uuid.Parse(userID)
+// Delete moves the product to the trash, from where it can be restored
+// until it's purged. A non-nil expectedVersion works as it does for
+// UpdateProductInput.
+func (s *ProductService) Delete(ctx context.Context, scope Scope, productID string, expectedVersion *int32) error {
+	owner, err := scope.owner()
+	if err != nil {
+		return ErrProductNotFound
+	}
 	pid, err := uuid.Parse(productID)
 	if err != nil {
 		return ErrProductNotFound
 	}
 
-	return s.queries.DeleteProduct(ctx, db.DeleteProductParams{
+	params := db.DeleteProductParams{
 		ID:     pid,
-		UserID: uid,
-	})
+		UserID: owner,
+	}
+	if expectedVersion != nil {
+		params.ExpectedVersion = sql.NullInt32{Int32: *expectedVersion, Valid: true}
+	}
+
+	deleted, err := s.queries.DeleteProduct(ctx, params)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		if expectedVersion != nil {
+			return s.missOrMismatch(ctx, pid, owner)
+		}
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// missOrMismatch explains why a versioned write touched no rows:
+// either the product isn't there or someone else changed it first
+func (s *ProductService) missOrMismatch(ctx context.Context, productID uuid.UUID, owner uuid.NullUUID) error {
+	if _, err := s.queries.GetProductByID(ctx, db.GetProductByIDParams{
+		ID:     productID,
+		UserID: owner,
+	}); err != nil {
+		return ErrProductNotFound
+	}
+	return ErrVersionMismatch
 }
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+// stock movement reasons
+const (
+	StockRestock    = "restock"
+	StockSale       = "sale"
+	StockAdjustment = "adjustment"
+	StockReturn     = "return"
+)
+
+var (
+	ErrInsufficientStock = errors.New("not enough stock")
+	// ErrStockDirection is a delta whose sign doesn't fit its reason,
+	// like a sale that adds stock
+	ErrStockDirection = errors.New("delta does not match the reason")
+	// ErrVariantStockInherited is a stock adjustment to a variant that
+	// has no stock of its own; it's the product's that has to change
+	ErrVariantStockInherited = errors.New("variant takes the product's stock")
+)
+
+type StockAdjustmentInput struct {
+	// Delta is added to the current stock; negative takes stock away
+	Delta  int32
+	Reason string
+	Note   string
+}
+
+type StockMovementPage struct {
+	Movements []db.StockMovement
+	Total     int64
+}
+
+// AdjustStock applies a delta to the product's stock and records it in
+// the ledger, in one transaction. The update is relative, so concurrent
+// adjustments don't overwrite each other, and it's refused with
+// ErrInsufficientStock if it would leave stock below zero.
+func (s *ProductService) AdjustStock(ctx context.Context, scope Scope, productID string, input StockAdjustmentInput) (db.Product, db.StockMovement, error) {
+	if err := input.check(); err != nil {
+		return db.Product{}, db.StockMovement{}, err
+	}
+
+	owner, err := scope.owner()
+	if err != nil {
+		return db.Product{}, db.StockMovement{}, ErrProductNotFound
+	}
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return db.Product{}, db.StockMovement{}, ErrProductNotFound
+	}
+
+	var (
+		product  db.Product
+		movement db.StockMovement
+	)
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		var err error
+		product, err = q.AdjustProductStock(ctx, db.AdjustProductStockParams{
+			Delta:  input.Delta,
+			ID:     pid,
+			UserID: owner,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return s.missOrShortfall(ctx, q, pid, owner)
+			}
+			return err
+		}
+
+		movement, err = recordStockMovement(ctx, q, scope, product, input.Delta, input.Reason, input.Note)
+		return err
+	})
+	return product, movement, err
+}
+
+// AdjustVariantStock is AdjustStock for a variant with stock of its
+// own. One that takes the product's stock is ErrVariantStockInherited.
+func (s *ProductService) AdjustVariantStock(ctx context.Context, scope Scope, productID, variantID string, input StockAdjustmentInput) (db.ProductVariant, db.StockMovement, error) {
+	if err := input.check(); err != nil {
+		return db.ProductVariant{}, db.StockMovement{}, err
+	}
+
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return db.ProductVariant{}, db.StockMovement{}, err
+	}
+	vid, err := uuid.Parse(variantID)
+	if err != nil {
+		return db.ProductVariant{}, db.StockMovement{}, ErrVariantNotFound
+	}
+
+	var (
+		variant  db.ProductVariant
+		movement db.StockMovement
+	)
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		var err error
+		variant, err = q.AdjustVariantStock(ctx, db.AdjustVariantStockParams{
+			Delta:     input.Delta,
+			ID:        vid,
+			ProductID: product.ID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return variantMissOrShortfall(ctx, q, vid, product.ID)
+			}
+			return err
+		}
+
+		movement, err = recordVariantStockMovement(ctx, q, scope, variant, input.Delta, input.Reason, input.Note)
+		return err
+	})
+	return variant, movement, err
+}
+
+func (s *ProductService) StockMovements(ctx context.Context, scope Scope, productID string, page Page) (StockMovementPage, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return StockMovementPage{}, err
+	}
+
+	movements, err := s.queries.ListStockMovements(ctx, db.ListStockMovementsParams{
+		ProductID: product.ID,
+		Limit:     page.Limit(),
+		Offset:    page.Offset(),
+	})
+	if err != nil {
+		return StockMovementPage{}, err
+	}
+
+	total, err := s.queries.CountStockMovements(ctx, product.ID)
+	if err != nil {
+		return StockMovementPage{}, err
+	}
+
+	return StockMovementPage{Movements: movements, Total: total}, nil
+}
+
+func (s *ProductService) VariantStockMovements(ctx context.Context, scope Scope, productID, variantID string, page Page) (StockMovementPage, error) {
+	variant, err := s.GetVariant(ctx, scope, productID, variantID)
+	if err != nil {
+		return StockMovementPage{}, err
+	}
+	id := uuid.NullUUID{UUID: variant.ID, Valid: true}
+
+	movements, err := s.queries.ListVariantStockMovements(ctx, db.ListVariantStockMovementsParams{
+		VariantID: id,
+		Limit:     page.Limit(),
+		Offset:    page.Offset(),
+	})
+	if err != nil {
+		return StockMovementPage{}, err
+	}
+
+	total, err := s.queries.CountVariantStockMovements(ctx, id)
+	if err != nil {
+		return StockMovementPage{}, err
+	}
+
+	return StockMovementPage{Movements: movements, Total: total}, nil
+}
+
+// check rejects a delta whose sign doesn't fit the reason
+func (input StockAdjustmentInput) check() error {
+	switch {
+	case input.Reason == StockSale && input.Delta > 0,
+		(input.Reason == StockRestock || input.Reason == StockReturn) && input.Delta < 0:
+		return ErrStockDirection
+	}
+	return nil
+}
+
+// missOrShortfall explains why AdjustProductStock matched no row
+func (s *ProductService) missOrShortfall(ctx context.Context, q *db.Queries, productID uuid.UUID, owner uuid.NullUUID) error {
+	if _, err := q.GetProductByID(ctx, db.GetProductByIDParams{
+		ID:     productID,
+		UserID: owner,
+	}); err != nil {
+		return ErrProductNotFound
+	}
+	return ErrInsufficientStock
+}
+
+// variantMissOrShortfall explains why AdjustVariantStock matched no row
+func variantMissOrShortfall(ctx context.Context, q *db.Queries, variantID, productID uuid.UUID) error {
+	variant, err := q.GetProductVariant(ctx, db.GetProductVariantParams{
+		ID:        variantID,
+		ProductID: productID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrVariantNotFound
+		}
+		return err
+	}
+	if !variant.Stock.Valid {
+		return ErrVariantStockInherited
+	}
+	return ErrInsufficientStock
+}
+
+// recordStockMovement appends a ledger entry for a stock change that
+// has just been applied to product. A zero delta records nothing.
+func recordStockMovement(ctx context.Context, q *db.Queries, scope Scope, product db.Product, delta int32, reason, note string) (db.StockMovement, error) {
+	if delta == 0 {
+		return db.StockMovement{}, nil
+	}
+
+	return q.CreateStockMovement(ctx, db.CreateStockMovementParams{
+		ProductID:  product.ID,
+		UserID:     scopeActor(scope),
+		Reason:     reason,
+		Delta:      delta,
+		StockAfter: product.Stock,
+		Note:       sql.NullString{String: note, Valid: note != ""},
+	})
+}
+
+// recordVariantStockMovement is recordStockMovement for a variant's
+// own stock. A variant going back to the product's stock counts as
+// going to zero.
+func recordVariantStockMovement(ctx context.Context, q *db.Queries, scope Scope, variant db.ProductVariant, delta int32, reason, note string) (db.StockMovement, error) {
+	if delta == 0 {
+		return db.StockMovement{}, nil
+	}
+
+	return q.CreateStockMovement(ctx, db.CreateStockMovementParams{
+		ProductID:  variant.ProductID,
+		VariantID:  uuid.NullUUID{UUID: variant.ID, Valid: true},
+		UserID:     scopeActor(scope),
+		Reason:     reason,
+		Delta:      delta,
+		StockAfter: variant.Stock.Int32,
+		Note:       sql.NullString{String: note, Valid: note != ""},
+	})
+}
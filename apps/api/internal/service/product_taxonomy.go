@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+func (s *ProductService) Categories(ctx context.Context, scope Scope, productID string) ([]db.Category, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queries.ListProductCategories(ctx, product.ID)
+}
+
+// SetCategories replaces the product's categories. They must belong to
+// the product's owner, which for admin scopes isn't necessarily the caller.
+func (s *ProductService) SetCategories(ctx context.Context, scope Scope, productID string, categoryIDs []string) ([]db.Category, error) {
+	ids := make([]uuid.UUID, 0, len(categoryIDs))
+	seen := make(map[uuid.UUID]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		cid, err := uuid.Parse(id)
+		if err != nil {
+			return nil, ErrInvalidCategory
+		}
+		if !seen[cid] {
+			seen[cid] = true
+			ids = append(ids, cid)
+		}
+	}
+
+	var categories []db.Category
+	err := s.withProduct(ctx, scope, productID, func(q *db.Queries, product db.Product) error {
+		if err := q.ClearProductCategories(ctx, product.ID); err != nil {
+			return err
+		}
+
+		for _, cid := range ids {
+			added, err := q.AddProductCategory(ctx, db.AddProductCategoryParams{
+				ProductID:  product.ID,
+				CategoryID: cid,
+				UserID:     product.UserID,
+			})
+			if err != nil {
+				return err
+			}
+			if added == 0 {
+				return ErrInvalidCategory
+			}
+		}
+
+		var err error
+		categories, err = q.ListProductCategories(ctx, product.ID)
+		return err
+	})
+	return categories, err
+}
+
+func (s *ProductService) Tags(ctx context.Context, scope Scope, productID string) ([]db.Tag, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queries.ListProductTags(ctx, product.ID)
+}
+
+// SetTags replaces the product's tags by name, creating any of the
+// owner's tags that don't exist yet. Names match ignoring case.
+func (s *ProductService) SetTags(ctx context.Context, scope Scope, productID string, names []string) ([]db.Tag, error) {
+	var tags []db.Tag
+	err := s.withProduct(ctx, scope, productID, func(q *db.Queries, product db.Product) error {
+		if err := q.ClearProductTags(ctx, product.ID); err != nil {
+			return err
+		}
+
+		for _, name := range NormalizeTagNames(names) {
+			tag, err := q.UpsertTag(ctx, db.UpsertTagParams{
+				UserID: product.UserID,
+				Name:   name,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := q.AddProductTag(ctx, db.AddProductTagParams{
+				ProductID: product.ID,
+				TagID:     tag.ID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		tags, err = q.ListProductTags(ctx, product.ID)
+		return err
+	})
+	return tags, err
+}
+
+// NormalizeTagNames trims names and drops blanks and case-insensitive
+// duplicates, keeping the first spelling of each
+func NormalizeTagNames(names []string) []string {
+	out := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// withProduct runs fn in a transaction holding the product's row lock,
+// so concurrent replacements of its links apply one after the other
+func (s *ProductService) withProduct(ctx context.Context, scope Scope, productID string, fn func(q *db.Queries, product db.Product) error) error {
+	owner, err := scope.owner()
+	if err != nil {
+		return ErrProductNotFound
+	}
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return ErrProductNotFound
+	}
+
+	return withTx(ctx, s.database, func(q *db.Queries) error {
+		product, err := q.GetProductForUpdate(ctx, db.GetProductForUpdateParams{
+			ID:     pid,
+			UserID: owner,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+
+		return fn(q, product)
+	})
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+type TrashPage struct {
+	Products []db.Product
+	Total    int64
+}
+
+// Trash lists deleted products, most recently deleted first
+func (s *ProductService) Trash(ctx context.Context, scope Scope, page Page) (TrashPage, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return TrashPage{}, err
+	}
+
+	products, err := s.queries.ListTrashedProducts(ctx, db.ListTrashedProductsParams{
+		UserID: owner,
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return TrashPage{}, err
+	}
+
+	total, err := s.queries.CountTrashedProducts(ctx, owner)
+	if err != nil {
+		return TrashPage{}, err
+	}
+
+	return TrashPage{Products: products, Total: total}, nil
+}
+
+// Restore takes a product back out of the trash
+func (s *ProductService) Restore(ctx context.Context, scope Scope, productID string) (db.Product, error) {
+	owner, err := scope.owner()
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
+
+	product, err := s.queries.RestoreProduct(ctx, db.RestoreProductParams{
+		ID:     pid,
+		UserID: owner,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Product{}, ErrProductNotFound
+		}
+		return db.Product{}, err
+	}
+
+	return product, nil
+}
+
+// Purge permanently deletes a product that is already in the trash
+func (s *ProductService) Purge(ctx context.Context, scope Scope, productID string) error {
+	owner, err := scope.owner()
+	if err != nil {
+		return ErrProductNotFound
+	}
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return ErrProductNotFound
+	}
+
+	purged, err := s.queries.PurgeProduct(ctx, db.PurgeProductParams{
+		ID:     pid,
+		UserID: owner,
+	})
+	if err != nil {
+		return err
+	}
+	if purged == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// PurgeTrash is a Job that runs every hour, permanently deleting
+// products that have sat in the trash for longer than the retention
+// period
+func (s *ProductService) PurgeTrash(ctx context.Context) {
+	background.Every(ctx, time.Hour, func(ctx context.Context) {
+		purged, err := s.queries.PurgeTrashedProducts(ctx, time.Now().Add(-s.trashRetention))
+		if err != nil {
+			slog.Error("failed to purge trashed products", "error", err)
+			return
+		}
+		if purged > 0 {
+			slog.Info("purged trashed products", "count", purged)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/google/uuid"
+)
+
+// MaxVariantsPerProduct keeps embedded variant lists a sane size
+const MaxVariantsPerProduct = 100
+
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	// ErrDuplicateSKU is a SKU another of the owner's variants already
+	// has, compared ignoring case
+	ErrDuplicateSKU = errors.New("sku is already in use")
+	// ErrDuplicateVariant is a second variant of a product with the
+	// same options
+	ErrDuplicateVariant = errors.New("product already has a variant with these options")
+	ErrTooManyVariants  = errors.New("product has too many variants")
+)
+
+// VariantInput is every field of a variant. A nil Price or Stock means
+// the variant takes the product's.
+type VariantInput struct {
+	SKU     string
+	Options map[string]string
+	Price   *money.Decimal
+	Stock   *int32
+}
+
+// ProductWithVariants is a product with its variants embedded
+type ProductWithVariants struct {
+	db.Product
+	Variants []db.ProductVariant `json:"variants"`
+}
+
+func (s *ProductService) Variants(ctx context.Context, scope Scope, productID string) ([]db.ProductVariant, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queries.ListProductVariants(ctx, product.ID)
+}
+
+func (s *ProductService) GetVariant(ctx context.Context, scope Scope, productID, variantID string) (db.ProductVariant, error) {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return db.ProductVariant{}, err
+	}
+	vid, err := uuid.Parse(variantID)
+	if err != nil {
+		return db.ProductVariant{}, ErrVariantNotFound
+	}
+
+	variant, err := s.queries.GetProductVariant(ctx, db.GetProductVariantParams{
+		ID:        vid,
+		ProductID: product.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ProductVariant{}, ErrVariantNotFound
+		}
+		return db.ProductVariant{}, err
+	}
+
+	return variant, nil
+}
+
+// CreateVariant adds a variant to the product. Its SKU must be unique
+// among all of the owner's variants and its options among the product's.
+// Any stock of its own opens the variant's ledger.
+func (s *ProductService) CreateVariant(ctx context.Context, scope Scope, productID string, input VariantInput) (db.ProductVariant, error) {
+	var variant db.ProductVariant
+	err := s.withProduct(ctx, scope, productID, func(q *db.Queries, product db.Product) error {
+		// the product row is locked, so the count can't race
+		count, err := q.CountProductVariants(ctx, product.ID)
+		if err != nil {
+			return err
+		}
+		if count >= MaxVariantsPerProduct {
+			return ErrTooManyVariants
+		}
+
+		fields, err := variantFields(input, product.Currency)
+		if err != nil {
+			return err
+		}
+
+		variant, err = q.CreateProductVariant(ctx, db.CreateProductVariantParams{
+			ProductID: product.ID,
+			UserID:    product.UserID,
+			Sku:       fields.Sku,
+			Options:   fields.Options,
+			Price:     fields.Price,
+			Stock:     fields.Stock,
+		})
+		if err != nil {
+			return variantError(err)
+		}
+
+		_, err = recordVariantStockMovement(ctx, q, scope, variant, variant.Stock.Int32, StockAdjustment, "initial stock")
+		return err
+	})
+	return variant, err
+}
+
+// UpdateVariant replaces every field of the variant. A change to its
+// stock goes in the ledger as an adjustment, the same as setting a
+// product's stock does; AdjustVariantStock is the way to add or take
+// stock without overwriting it.
+func (s *ProductService) UpdateVariant(ctx context.Context, scope Scope, productID, variantID string, input VariantInput) (db.ProductVariant, error) {
+	vid, err := uuid.Parse(variantID)
+	if err != nil {
+		return db.ProductVariant{}, ErrVariantNotFound
+	}
+
+	var variant db.ProductVariant
+	err = s.withProduct(ctx, scope, productID, func(q *db.Queries, product db.Product) error {
+		fields, err := variantFields(input, product.Currency)
+		if err != nil {
+			return err
+		}
+
+		before, err := q.GetProductVariantForUpdate(ctx, db.GetProductVariantForUpdateParams{
+			ID:        vid,
+			ProductID: product.ID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrVariantNotFound
+			}
+			return err
+		}
+
+		variant, err = q.UpdateProductVariant(ctx, db.UpdateProductVariantParams{
+			ID:        vid,
+			ProductID: product.ID,
+			Sku:       fields.Sku,
+			Options:   fields.Options,
+			Price:     fields.Price,
+			Stock:     fields.Stock,
+		})
+		if err != nil {
+			return variantError(err)
+		}
+
+		_, err = recordVariantStockMovement(ctx, q, scope, variant, variant.Stock.Int32-before.Stock.Int32, StockAdjustment, "set by variant update")
+		return err
+	})
+	return variant, err
+}
+
+func (s *ProductService) DeleteVariant(ctx context.Context, scope Scope, productID, variantID string) error {
+	product, err := s.GetByID(ctx, scope, productID)
+	if err != nil {
+		return err
+	}
+	vid, err := uuid.Parse(variantID)
+	if err != nil {
+		return ErrVariantNotFound
+	}
+
+	deleted, err := s.queries.DeleteProductVariant(ctx, db.DeleteProductVariantParams{
+		ID:        vid,
+		ProductID: product.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrVariantNotFound
+	}
+
+	return nil
+}
+
+// withVariants embeds each product's variants, fetched in one query
+func (s *ProductService) withVariants(ctx context.Context, products []db.Product) ([]ProductWithVariants, error) {
+	ids := make([]uuid.UUID, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	variants, err := s.queries.ListVariantsForProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := make(map[uuid.UUID][]db.ProductVariant, len(products))
+	for _, v := range variants {
+		byProduct[v.ProductID] = append(byProduct[v.ProductID], v)
+	}
+
+	out := make([]ProductWithVariants, len(products))
+	for i, p := range products {
+		out[i] = ProductWithVariants{Product: p, Variants: byProduct[p.ID]}
+		if out[i].Variants == nil {
+			out[i].Variants = []db.ProductVariant{}
+		}
+	}
+	return out, nil
+}
+
+// variantFields turns input into column values. Option names are
+// trimmed and lower-cased and values trimmed, so "Size" and "size "
+// are the same option. A price override is checked against the
+// product's currency.
+func variantFields(input VariantInput, currency string) (db.CreateProductVariantParams, error) {
+	options := make(map[string]string, len(input.Options))
+	for name, value := range input.Options {
+		options[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	// map keys marshal sorted, and jsonb ignores order anyway
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return db.CreateProductVariantParams{}, err
+	}
+
+	fields := db.CreateProductVariantParams{
+		Sku:     strings.TrimSpace(input.SKU),
+		Options: encoded,
+	}
+	if input.Price != nil {
+		if err := CheckPrice(*input.Price, currency); err != nil {
+			return db.CreateProductVariantParams{}, err
+		}
+		fields.Price = money.NullDecimal{Decimal: *input.Price, Valid: true}
+	}
+	if input.Stock != nil {
+		fields.Stock = sql.NullInt32{Int32: *input.Stock, Valid: true}
+	}
+	return fields, nil
+}
+
+// variantError tells apart the two ways a variant can clash
+func variantError(err error) error {
+	if pgErrorCode(err) != pgUniqueViolation {
+		return err
+	}
+	if pgConstraint(err) == "idx_product_variants_user_sku" {
+		return ErrDuplicateSKU
+	}
+	return ErrDuplicateVariant
+}
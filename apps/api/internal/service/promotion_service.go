@@ -0,0 +1,552 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
+	"github.com/google/uuid"
+)
+
+// promotion kinds
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+)
+
+// reasons a coupon doesn't apply to a basket
+const (
+	CouponInactive   = "inactive"
+	CouponNotStarted = "not_started"
+	CouponExpired    = "expired"
+	// the promotion is in another currency from the basket
+	CouponCurrency  = "currency"
+	CouponMinOrder  = "min_order"
+	CouponUsedUp    = "usage_limit"
+	CouponUserLimit = "user_limit"
+	// nothing in the basket is one of the promotion's products, or the
+	// discount rounds to nothing
+	CouponNothingToDiscount = "nothing_to_discount"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrDuplicateCouponCode is a code another promotion already has,
+	// compared ignoring case
+	ErrDuplicateCouponCode = errors.New("coupon code is already in use")
+	// ErrInvalidPromotionValue is a percentage over 100 or an amount
+	// finer than its currency allows
+	ErrInvalidPromotionValue = errors.New("invalid promotion value")
+	// ErrPromotionCurrency is a fixed discount or minimum order without
+	// a currency to be in
+	ErrPromotionCurrency = errors.New("promotion needs a currency")
+	ErrPromotionWindow   = errors.New("promotion must end after it starts")
+	// ErrInvalidPromotionProduct is a product to scope a promotion to
+	// that doesn't exist or is in the trash
+	ErrInvalidPromotionProduct = errors.New("unknown product")
+	ErrCouponNotFound          = errors.New("unknown coupon code")
+)
+
+// CouponError is a coupon that exists but can't be used on the basket
+type CouponError struct {
+	// Reason is one of the Coupon* constants
+	Reason string
+}
+
+func (e *CouponError) Error() string { return "coupon cannot be used: " + e.Reason }
+
+// onePercent turns a percentage into a factor
+var onePercent = money.NewDecimal(1, 2)
+
+type PromotionService struct {
+	database *sql.DB
+	queries  db.Querier
+}
+
+func NewPromotionService(database *sql.DB, queries db.Querier) *PromotionService {
+	return &PromotionService{
+		database: database,
+		queries:  queries,
+	}
+}
+
+// PromotionInput is every field of a promotion. An empty Code makes it
+// apply by itself; empty ProductIDs make it apply to the whole basket.
+type PromotionInput struct {
+	Name           string
+	Code           string
+	Kind           string
+	Value          money.Decimal
+	Currency       string
+	MinOrder       *money.Decimal
+	MaxUses        *int32
+	MaxUsesPerUser *int32
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	Active         bool
+	ProductIDs     []string
+}
+
+// Promotion is a promotion with the products it's scoped to
+type Promotion struct {
+	db.Promotion
+	ProductIDs []uuid.UUID `json:"product_ids"`
+}
+
+type PromotionPage struct {
+	Promotions []db.Promotion
+	Total      int64
+}
+
+// AppliedDiscount is what one promotion took off a basket
+type AppliedDiscount struct {
+	PromotionID uuid.UUID   `json:"promotion_id"`
+	Name        string      `json:"name"`
+	Code        *string     `json:"code"`
+	Amount      money.Money `json:"amount"`
+}
+
+// Evaluation is a basket priced with its promotions
+type Evaluation struct {
+	Subtotal  money.Money       `json:"subtotal"`
+	Discounts []AppliedDiscount `json:"discounts"`
+	Discount  money.Money       `json:"discount"`
+	Total     money.Money       `json:"total"`
+	// CouponRejection says why the coupon asked for didn't apply
+	CouponRejection string `json:"coupon_rejection,omitempty"`
+}
+
+func (s *PromotionService) List(ctx context.Context, page Page) (PromotionPage, error) {
+	promotions, err := s.queries.ListPromotions(ctx, db.ListPromotionsParams{
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return PromotionPage{}, err
+	}
+
+	total, err := s.queries.CountPromotions(ctx)
+	if err != nil {
+		return PromotionPage{}, err
+	}
+
+	return PromotionPage{Promotions: promotions, Total: total}, nil
+}
+
+func (s *PromotionService) Get(ctx context.Context, promotionID string) (Promotion, error) {
+	pid, err := uuid.Parse(promotionID)
+	if err != nil {
+		return Promotion{}, ErrPromotionNotFound
+	}
+
+	promotion, err := s.queries.GetPromotion(ctx, pid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Promotion{}, ErrPromotionNotFound
+		}
+		return Promotion{}, err
+	}
+
+	return withPromotionProducts(ctx, s.queries, promotion)
+}
+
+// Create adds a promotion on behalf of the admin userID
+func (s *PromotionService) Create(ctx context.Context, userID string, input PromotionInput) (Promotion, error) {
+	fields, products, err := promotionFields(input)
+	if err != nil {
+		return Promotion{}, err
+	}
+	fields.CreatedBy = scopeActor(Scope{UserID: userID})
+
+	var promotion Promotion
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		created, err := q.CreatePromotion(ctx, fields)
+		if err != nil {
+			return promotionError(err)
+		}
+		promotion, err = setPromotionProducts(ctx, q, created, products)
+		return err
+	})
+	return promotion, err
+}
+
+// Update replaces every field of the promotion, its products included.
+// Past redemptions still count against the new limits.
+func (s *PromotionService) Update(ctx context.Context, promotionID string, input PromotionInput) (Promotion, error) {
+	pid, err := uuid.Parse(promotionID)
+	if err != nil {
+		return Promotion{}, ErrPromotionNotFound
+	}
+	fields, products, err := promotionFields(input)
+	if err != nil {
+		return Promotion{}, err
+	}
+
+	var promotion Promotion
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		updated, err := q.UpdatePromotion(ctx, db.UpdatePromotionParams{
+			ID:             pid,
+			Name:           fields.Name,
+			Code:           fields.Code,
+			Kind:           fields.Kind,
+			Value:          fields.Value,
+			Currency:       fields.Currency,
+			MinOrder:       fields.MinOrder,
+			MaxUses:        fields.MaxUses,
+			MaxUsesPerUser: fields.MaxUsesPerUser,
+			StartsAt:       fields.StartsAt,
+			EndsAt:         fields.EndsAt,
+			Active:         fields.Active,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrPromotionNotFound
+			}
+			return promotionError(err)
+		}
+		if err := q.ClearPromotionProducts(ctx, updated.ID); err != nil {
+			return err
+		}
+		promotion, err = setPromotionProducts(ctx, q, updated, products)
+		return err
+	})
+	return promotion, err
+}
+
+// Delete removes the promotion. Orders keep the discount they got.
+func (s *PromotionService) Delete(ctx context.Context, promotionID string) error {
+	pid, err := uuid.Parse(promotionID)
+	if err != nil {
+		return ErrPromotionNotFound
+	}
+
+	deleted, err := s.queries.DeletePromotion(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrPromotionNotFound
+	}
+
+	return nil
+}
+
+// Evaluate prices a basket for userID at current prices and works out
+// which promotions it gets, without placing an order or using any up.
+// The basket can hold what checkout would take: published products
+// out of the trash, and userID's own. Unlike checkout, a coupon that doesn't apply isn't an error:
+// the evaluation says why in CouponRejection.
+func (s *PromotionService) Evaluate(ctx context.Context, userID string, items []OrderItemInput, couponCode string) (Evaluation, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return Evaluation{}, ErrForbidden
+	}
+	lines, err := orderLines(items)
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	for _, l := range lines {
+		product, err := s.queries.GetProductByID(ctx, db.GetProductByIDParams{ID: l.productID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return Evaluation{}, &OrderItemError{Index: l.index, Err: ErrProductUnavailable}
+			}
+			return Evaluation{}, err
+		}
+		if !forSale(product, uid) {
+			return Evaluation{}, &OrderItemError{Index: l.index, Err: ErrProductUnavailable}
+		}
+		l.product = product
+	}
+	b, err := basketOf(lines)
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	return applyPromotions(ctx, s.queries, uid, b, couponCode, false)
+}
+
+// basketLine is what one product in a basket comes to
+type basketLine struct {
+	productID uuid.UUID
+	amount    money.Decimal
+}
+
+// basket is a priced set of order lines, all in one currency
+type basket struct {
+	lines    []basketLine
+	currency string
+	subtotal money.Decimal
+}
+
+// applyPromotions works out the discounts b gets: the coupon behind
+// code first, if any, then every automatic promotion, oldest first.
+// Each takes its share of what's left, so discounts never add up to
+// more than the subtotal. With lock set the promotions are locked, in
+// ID order so two checkouts can't deadlock, so that the usage counted
+// here still holds when the caller records redemptions in the same
+// transaction.
+func applyPromotions(ctx context.Context, q db.Querier, userID uuid.UUID, b basket, code string, lock bool) (Evaluation, error) {
+	now := time.Now()
+
+	var candidates []db.Promotion
+	if code = strings.TrimSpace(code); code != "" {
+		coupon, err := q.GetPromotionByCode(ctx, code)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return Evaluation{}, ErrCouponNotFound
+			}
+			return Evaluation{}, err
+		}
+		candidates = append(candidates, coupon)
+	}
+	automatic, err := q.ListAutomaticPromotions(ctx, now)
+	if err != nil {
+		return Evaluation{}, err
+	}
+	candidates = append(candidates, automatic...)
+
+	if lock {
+		ids := make([]uuid.UUID, len(candidates))
+		for i, p := range candidates {
+			ids[i] = p.ID
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return bytes.Compare(ids[i][:], ids[j][:]) < 0
+		})
+		locked := make(map[uuid.UUID]db.Promotion, len(ids))
+		for _, id := range ids {
+			p, err := q.LockPromotion(ctx, id)
+			if err != nil {
+				return Evaluation{}, err
+			}
+			locked[id] = p
+		}
+		// re-read under the lock, in case an admin just changed one
+		for i, p := range candidates {
+			candidates[i] = locked[p.ID]
+		}
+	}
+
+	eval := Evaluation{
+		Subtotal:  money.New(b.subtotal, b.currency),
+		Discounts: []AppliedDiscount{},
+	}
+	remaining := b.subtotal
+	for i, p := range candidates {
+		amount, reason, err := promotionDiscount(ctx, q, p, userID, b, now)
+		if err != nil {
+			return Evaluation{}, err
+		}
+		if reason == "" {
+			if amount.Cmp(remaining) > 0 {
+				amount = remaining
+			}
+			if amount.IsZero() {
+				reason = CouponNothingToDiscount
+			}
+		}
+		if reason != "" {
+			if i == 0 && code != "" {
+				eval.CouponRejection = reason
+			}
+			continue
+		}
+
+		remaining = remaining.Sub(amount)
+		applied := AppliedDiscount{
+			PromotionID: p.ID,
+			Name:        p.Name,
+			Amount:      money.New(amount, b.currency),
+		}
+		if p.Code.Valid {
+			applied.Code = &p.Code.String
+		}
+		eval.Discounts = append(eval.Discounts, applied)
+	}
+
+	eval.Discount = money.New(b.subtotal.Sub(remaining), b.currency)
+	eval.Total = money.New(remaining, b.currency)
+	return eval, nil
+}
+
+// promotionDiscount is what p would take off b on its own, or why it
+// takes nothing. Percentages are rounded to the currency's minor units
+// and fixed amounts capped at what they apply to.
+func promotionDiscount(ctx context.Context, q db.Querier, p db.Promotion, userID uuid.UUID, b basket, now time.Time) (money.Decimal, string, error) {
+	var none money.Decimal
+
+	switch {
+	case !p.Active:
+		return none, CouponInactive, nil
+	case p.StartsAt.Valid && now.Before(p.StartsAt.Time):
+		return none, CouponNotStarted, nil
+	case p.EndsAt.Valid && !now.Before(p.EndsAt.Time):
+		return none, CouponExpired, nil
+	case p.Currency.Valid && p.Currency.String != b.currency:
+		return none, CouponCurrency, nil
+	case p.MinOrder.Valid && b.subtotal.Cmp(p.MinOrder.Decimal) < 0:
+		return none, CouponMinOrder, nil
+	}
+
+	if p.MaxUses.Valid {
+		used, err := q.CountPromotionRedemptions(ctx, p.ID)
+		if err != nil {
+			return none, "", err
+		}
+		if used >= int64(p.MaxUses.Int32) {
+			return none, CouponUsedUp, nil
+		}
+	}
+	if p.MaxUsesPerUser.Valid {
+		used, err := q.CountUserPromotionRedemptions(ctx, db.CountUserPromotionRedemptionsParams{
+			PromotionID: p.ID,
+			UserID:      userID,
+		})
+		if err != nil {
+			return none, "", err
+		}
+		if used >= int64(p.MaxUsesPerUser.Int32) {
+			return none, CouponUserLimit, nil
+		}
+	}
+
+	products, err := q.ListPromotionProducts(ctx, p.ID)
+	if err != nil {
+		return none, "", err
+	}
+	scoped := make(map[uuid.UUID]bool, len(products))
+	for _, id := range products {
+		scoped[id] = true
+	}
+	var eligible money.Decimal
+	for _, l := range b.lines {
+		if len(scoped) == 0 || scoped[l.productID] {
+			eligible = eligible.Add(l.amount)
+		}
+	}
+	if eligible.IsZero() {
+		return none, CouponNothingToDiscount, nil
+	}
+
+	if p.Kind == PromotionPercentage {
+		return eligible.Mul(p.Value).Mul(onePercent).Round(money.MinorUnits(b.currency)), "", nil
+	}
+	if p.Value.Cmp(eligible) > 0 {
+		return eligible, "", nil
+	}
+	return p.Value, "", nil
+}
+
+// promotionFields checks input and turns it into column values, plus
+// the product IDs to scope the promotion to
+func promotionFields(input PromotionInput) (db.CreatePromotionParams, []uuid.UUID, error) {
+	fields := db.CreatePromotionParams{
+		Name:   strings.TrimSpace(input.Name),
+		Kind:   input.Kind,
+		Value:  input.Value,
+		Active: input.Active,
+	}
+	if code := strings.TrimSpace(input.Code); code != "" {
+		fields.Code = sql.NullString{String: code, Valid: true}
+	}
+	if input.Currency != "" {
+		fields.Currency = sql.NullString{String: input.Currency, Valid: true}
+	}
+
+	if input.Kind == PromotionFixed || input.MinOrder != nil {
+		if !fields.Currency.Valid {
+			return db.CreatePromotionParams{}, nil, ErrPromotionCurrency
+		}
+	}
+	if input.Value.Sign() <= 0 {
+		return db.CreatePromotionParams{}, nil, ErrInvalidPromotionValue
+	}
+	if input.Kind == PromotionPercentage {
+		if input.Value.Cmp(money.NewDecimal(100, 0)) > 0 || input.Value.MinScale() > 2 {
+			return db.CreatePromotionParams{}, nil, ErrInvalidPromotionValue
+		}
+	} else if err := CheckPrice(input.Value, input.Currency); err != nil {
+		return db.CreatePromotionParams{}, nil, ErrInvalidPromotionValue
+	}
+	if input.MinOrder != nil {
+		if err := CheckPrice(*input.MinOrder, input.Currency); err != nil {
+			return db.CreatePromotionParams{}, nil, err
+		}
+		fields.MinOrder = money.NullDecimal{Decimal: *input.MinOrder, Valid: true}
+	}
+
+	if input.MaxUses != nil {
+		fields.MaxUses = sql.NullInt32{Int32: *input.MaxUses, Valid: true}
+	}
+	if input.MaxUsesPerUser != nil {
+		fields.MaxUsesPerUser = sql.NullInt32{Int32: *input.MaxUsesPerUser, Valid: true}
+	}
+	if input.StartsAt != nil {
+		fields.StartsAt = sql.NullTime{Time: *input.StartsAt, Valid: true}
+	}
+	if input.EndsAt != nil {
+		if input.StartsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+			return db.CreatePromotionParams{}, nil, ErrPromotionWindow
+		}
+		fields.EndsAt = sql.NullTime{Time: *input.EndsAt, Valid: true}
+	}
+
+	products := make([]uuid.UUID, 0, len(input.ProductIDs))
+	seen := make(map[uuid.UUID]bool, len(input.ProductIDs))
+	for _, id := range input.ProductIDs {
+		pid, err := uuid.Parse(id)
+		if err != nil {
+			return db.CreatePromotionParams{}, nil, ErrInvalidPromotionProduct
+		}
+		if !seen[pid] {
+			seen[pid] = true
+			products = append(products, pid)
+		}
+	}
+	return fields, products, nil
+}
+
+// setPromotionProducts scopes promotion to products, which must all
+// exist and be out of the trash
+func setPromotionProducts(ctx context.Context, q *db.Queries, promotion db.Promotion, products []uuid.UUID) (Promotion, error) {
+	for _, pid := range products {
+		added, err := q.AddPromotionProduct(ctx, db.AddPromotionProductParams{
+			PromotionID: promotion.ID,
+			ProductID:   pid,
+		})
+		if err != nil {
+			return Promotion{}, err
+		}
+		if added == 0 {
+			return Promotion{}, ErrInvalidPromotionProduct
+		}
+	}
+
+	return withPromotionProducts(ctx, q, promotion)
+}
+
+func withPromotionProducts(ctx context.Context, q db.Querier, promotion db.Promotion) (Promotion, error) {
+	products, err := q.ListPromotionProducts(ctx, promotion.ID)
+	if err != nil {
+		return Promotion{}, err
+	}
+	if products == nil {
+		products = []uuid.UUID{}
+	}
+	return Promotion{Promotion: promotion, ProductIDs: products}, nil
+}
+
+func promotionError(err error) error {
+	if pgErrorCode(err) == pgUniqueViolation {
+		return ErrDuplicateCouponCode
+	}
+	return err
+}
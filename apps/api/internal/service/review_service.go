@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+// review statuses
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrAlreadyReviewed is a second review of the same product by the
+	// same user; they should edit the first
+	ErrAlreadyReviewed = errors.New("you have already reviewed this product")
+	ErrOwnProduct      = errors.New("you cannot review your own product")
+)
+
+type ReviewService struct {
+	database *sql.DB
+	queries  db.Querier
+}
+
+func NewReviewService(database *sql.DB, queries db.Querier) *ReviewService {
+	return &ReviewService{
+		database: database,
+		queries:  queries,
+	}
+}
+
+type ReviewInput struct {
+	// Rating is 1 to 5 stars
+	Rating int16
+	Body   string
+}
+
+type ReviewPage struct {
+	Reviews []db.Review
+	Total   int64
+}
+
+// ValidReviewStatus reports whether status is one a review can be in
+func ValidReviewStatus(status string) bool {
+	return status == ReviewPending || status == ReviewApproved || status == ReviewRejected
+}
+
+// List returns the product's approved reviews, newest first, along
+// with the viewer's own whatever its status. Any product that can be
+// reviewed can be listed, and so can the viewer's own.
+func (s *ReviewService) List(ctx context.Context, userID, productID string, page Page) (ReviewPage, error) {
+	product, err := s.product(ctx, userID, productID)
+	if err != nil {
+		return ReviewPage{}, err
+	}
+	viewer := scopeActor(Scope{UserID: userID})
+
+	reviews, err := s.queries.ListProductReviews(ctx, db.ListProductReviewsParams{
+		ProductID: product.ID,
+		ViewerID:  viewer,
+		Limit:     page.Limit(),
+		Offset:    page.Offset(),
+	})
+	if err != nil {
+		return ReviewPage{}, err
+	}
+
+	total, err := s.queries.CountProductReviews(ctx, db.CountProductReviewsParams{
+		ProductID: product.ID,
+		ViewerID:  viewer,
+	})
+	if err != nil {
+		return ReviewPage{}, err
+	}
+
+	return ReviewPage{Reviews: reviews, Total: total}, nil
+}
+
+// Create reviews a product on behalf of userID. The review waits in
+// moderation and doesn't count toward the rating until it's approved.
+// Owners can't review their own products.
+func (s *ReviewService) Create(ctx context.Context, userID, productID string, input ReviewInput) (db.Review, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Review{}, ErrForbidden
+	}
+	product, err := s.product(ctx, userID, productID)
+	if err != nil {
+		return db.Review{}, err
+	}
+	if product.UserID == uid {
+		return db.Review{}, ErrOwnProduct
+	}
+
+	review, err := s.queries.CreateReview(ctx, db.CreateReviewParams{
+		ProductID: product.ID,
+		UserID:    uid,
+		Rating:    input.Rating,
+		Body:      strings.TrimSpace(input.Body),
+	})
+	if err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return db.Review{}, ErrAlreadyReviewed
+		}
+		return db.Review{}, err
+	}
+
+	return review, nil
+}
+
+// Update lets the author change their review. It goes back into
+// moderation, so an approved review stops counting until it's
+// approved again.
+func (s *ReviewService) Update(ctx context.Context, userID, reviewID string, input ReviewInput) (db.Review, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Review{}, ErrForbidden
+	}
+	rid, err := uuid.Parse(reviewID)
+	if err != nil {
+		return db.Review{}, ErrReviewNotFound
+	}
+
+	var review db.Review
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		before, err := lockReview(ctx, q, rid)
+		if err != nil {
+			return err
+		}
+		if before.UserID != uid {
+			return ErrReviewNotFound
+		}
+
+		review, err = q.UpdateReview(ctx, db.UpdateReviewParams{
+			ID:     rid,
+			Rating: input.Rating,
+			Body:   strings.TrimSpace(input.Body),
+		})
+		if err != nil {
+			return err
+		}
+		return adjustRating(ctx, q, before, review)
+	})
+	return review, err
+}
+
+// Delete removes a review. Users can delete their own; moderators,
+// with an AllUsers scope, anyone's. A trigger takes an approved review
+// out of the product's rating, since reviews are also deleted along
+// with their author, where the service never sees them go.
+func (s *ReviewService) Delete(ctx context.Context, scope Scope, reviewID string) error {
+	rid, err := uuid.Parse(reviewID)
+	if err != nil {
+		return ErrReviewNotFound
+	}
+
+	return withTx(ctx, s.database, func(q *db.Queries) error {
+		review, err := lockReview(ctx, q, rid)
+		if err != nil {
+			return err
+		}
+		if !scope.AllUsers && review.UserID.String() != scope.UserID {
+			return ErrReviewNotFound
+		}
+
+		return q.DeleteReview(ctx, rid)
+	})
+}
+
+// Queue lists reviews in status, oldest first, for moderators. An
+// empty status lists every review.
+func (s *ReviewService) Queue(ctx context.Context, status string, page Page) (ReviewPage, error) {
+	filter := sql.NullString{String: status, Valid: status != ""}
+
+	reviews, err := s.queries.ListReviewsByStatus(ctx, db.ListReviewsByStatusParams{
+		Status: filter,
+		Limit:  page.Limit(),
+		Offset: page.Offset(),
+	})
+	if err != nil {
+		return ReviewPage{}, err
+	}
+
+	total, err := s.queries.CountReviewsByStatus(ctx, filter)
+	if err != nil {
+		return ReviewPage{}, err
+	}
+
+	return ReviewPage{Reviews: reviews, Total: total}, nil
+}
+
+// Moderate approves or rejects a review, or puts it back to pending,
+// and moves the product's rating totals to match in the same
+// transaction
+func (s *ReviewService) Moderate(ctx context.Context, moderatorID, reviewID, status string) (db.Review, error) {
+	rid, err := uuid.Parse(reviewID)
+	if err != nil {
+		return db.Review{}, ErrReviewNotFound
+	}
+
+	var review db.Review
+	err = withTx(ctx, s.database, func(q *db.Queries) error {
+		before, err := lockReview(ctx, q, rid)
+		if err != nil {
+			return err
+		}
+
+		review, err = q.SetReviewStatus(ctx, db.SetReviewStatusParams{
+			ID:          rid,
+			Status:      status,
+			ModeratedBy: scopeActor(Scope{UserID: moderatorID}),
+		})
+		if err != nil {
+			return err
+		}
+		return adjustRating(ctx, q, before, review)
+	})
+	return review, err
+}
+
+// product finds a product userID can see reviews of: one that's for
+// sale to them and out of the trash
+func (s *ReviewService) product(ctx context.Context, userID, productID string) (db.Product, error) {
+	pid, err := uuid.Parse(productID)
+	if err != nil {
+		return db.Product{}, ErrProductNotFound
+	}
+
+	product, err := s.queries.GetProductByID(ctx, db.GetProductByIDParams{ID: pid})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Product{}, ErrProductNotFound
+		}
+		return db.Product{}, err
+	}
+	uid, _ := uuid.Parse(userID)
+	if !forSale(product, uid) {
+		return db.Product{}, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func lockReview(ctx context.Context, q *db.Queries, id uuid.UUID) (db.Review, error) {
+	review, err := q.GetReviewForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Review{}, ErrReviewNotFound
+		}
+		return db.Review{}, err
+	}
+	return review, nil
+}
+
+// adjustRating moves the product's rating totals by the difference
+// between a review before and after a change. Only approved reviews
+// count. Deletes are left to the reviews_unrate_on_delete trigger.
+func adjustRating(ctx context.Context, q *db.Queries, before, after db.Review) error {
+	countBefore, sumBefore := ratingWeight(before)
+	countAfter, sumAfter := ratingWeight(after)
+	if countBefore == countAfter && sumBefore == sumAfter {
+		return nil
+	}
+
+	return q.AdjustProductRating(ctx, db.AdjustProductRatingParams{
+		CountDelta: countAfter - countBefore,
+		SumDelta:   sumAfter - sumBefore,
+		ID:         after.ProductID,
+	})
+}
+
+func ratingWeight(r db.Review) (count, sum int32) {
+	if r.Status != ReviewApproved {
+		return 0, 0
+	}
+	return 1, int32(r.Rating)
+}
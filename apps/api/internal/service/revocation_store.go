@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/background"
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+type cachedRevocation struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type cachedSession struct {
+	version   int32
+	disabled  bool
+	expiresAt time.Time
+}
+
+// RevocationStore decides whether an access token is still good.
+// Revoked JTIs, per-user token versions and disabled flags live in Postgres; lookups
+// are cached in-process for cacheTTL so most requests never hit the DB.
+// Revocations made by this process are visible immediately, those made
+// by other instances within cacheTTL.
+type RevocationStore struct {
+	queries  db.Querier
+	cacheTTL time.Duration
+
+	mu       sync.RWMutex
+	jtis     map[uuid.UUID]cachedRevocation
+	sessions map[uuid.UUID]cachedSession
+}
+
+func NewRevocationStore(queries db.Querier, cacheTTL time.Duration) *RevocationStore {
+	return &RevocationStore{
+		queries:  queries,
+		cacheTTL: cacheTTL,
+		jtis:     make(map[uuid.UUID]cachedRevocation),
+		sessions: make(map[uuid.UUID]cachedSession),
+	}
+}
+
+// Revoke blacklists a single token until its own expiry
+func (s *RevocationStore) Revoke(ctx context.Context, jti, userID uuid.UUID, expiresAt time.Time) error {
+	if err := s.queries.RevokeToken(ctx, db.RevokeTokenParams{
+		Jti:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.jtis[jti] = cachedRevocation{revoked: true, expiresAt: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAll invalidates every token issued to the user so far
+// by bumping their token version
+func (s *RevocationStore) RevokeAll(ctx context.Context, userID uuid.UUID) (int32, error) {
+	version, err := s.queries.IncrementUserTokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.Forget(userID)
+	return version, nil
+}
+
+// Forget drops the cached session state for a user, so the next
+// check reads it fresh. Call it after changing token_version or
+// disabled_at outside the store.
+func (s *RevocationStore) Forget(userID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.sessions, userID)
+	s.mu.Unlock()
+}
+
+// IsRevoked reports whether the token was revoked on its own, belongs
+// to a disabled account, or was issued under an older token version
+// than the user's current one
+func (s *RevocationStore) IsRevoked(ctx context.Context, jti, userID string, version int64) (bool, error) {
+	tokenID, err := uuid.Parse(jti)
+	if err != nil {
+		return true, nil
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return true, nil
+	}
+
+	session, err := s.session(ctx, uid)
+	if err != nil {
+		// the account is gone, and its tokens with it
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, err
+	}
+	if session.disabled || version != int64(session.version) {
+		return true, nil
+	}
+
+	return s.isJTIRevoked(ctx, tokenID)
+}
+
+func (s *RevocationStore) session(ctx context.Context, userID uuid.UUID) (cachedSession, error) {
+	s.mu.RLock()
+	cached, ok := s.sessions[userID]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	state, err := s.queries.GetUserSessionState(ctx, userID)
+	if err != nil {
+		return cachedSession{}, err
+	}
+
+	session := cachedSession{
+		version:   state.TokenVersion,
+		disabled:  state.DisabledAt.Valid,
+		expiresAt: time.Now().Add(s.cacheTTL),
+	}
+	s.mu.Lock()
+	s.sessions[userID] = session
+	s.mu.Unlock()
+	return session, nil
+}
+
+func (s *RevocationStore) isJTIRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	s.mu.RLock()
+	cached, ok := s.jtis[jti]
+	s.mu.RUnlock()
+	if ok && (cached.revoked || time.Now().Before(cached.expiresAt)) {
+		return cached.revoked, nil
+	}
+
+	revoked, err := s.queries.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	// only negative answers need a TTL — a revocation never un-happens
+	s.mu.Lock()
+	s.jtis[jti] = cachedRevocation{revoked: revoked, expiresAt: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return revoked, nil
+}
+
+// Cleanup is a Job that runs every minute, dropping expired cache
+// entries and revoked_tokens rows whose tokens have expired anyway
+func (s *RevocationStore) Cleanup(ctx context.Context) {
+	background.Every(ctx, time.Minute, func(ctx context.Context) {
+		now := time.Now()
+		s.mu.Lock()
+		for jti, c := range s.jtis {
+			if now.After(c.expiresAt) {
+				delete(s.jtis, jti)
+			}
+		}
+		for userID, c := range s.sessions {
+			if now.After(c.expiresAt) {
+				delete(s.sessions, userID)
+			}
+		}
+		s.mu.Unlock()
+
+		if err := s.queries.DeleteExpiredRevokedTokens(ctx); err != nil {
+			slog.Error("failed to delete expired revoked tokens", "error", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("a tag with this name already exists")
+)
+
+// TagService manages a user's tags. Tags are also created on the fly
+// when products are tagged (see ProductService.SetTags); names are
+// unique per user, ignoring case.
+type TagService struct {
+	queries db.Querier
+}
+
+func NewTagService(queries db.Querier) *TagService {
+	return &TagService{queries: queries}
+}
+
+func (s *TagService) List(ctx context.Context, userID string) ([]db.Tag, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrForbidden
+	}
+
+	return s.queries.ListTags(ctx, uid)
+}
+
+func (s *TagService) Create(ctx context.Context, userID, name string) (db.Tag, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Tag{}, ErrForbidden
+	}
+
+	tag, err := s.queries.CreateTag(ctx, db.CreateTagParams{
+		UserID: uid,
+		Name:   strings.TrimSpace(name),
+	})
+	if pgErrorCode(err) == pgUniqueViolation {
+		return db.Tag{}, ErrTagExists
+	}
+	return tag, err
+}
+
+func (s *TagService) Rename(ctx context.Context, userID, tagID, name string) (db.Tag, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return db.Tag{}, ErrTagNotFound
+	}
+	tid, err := uuid.Parse(tagID)
+	if err != nil {
+		return db.Tag{}, ErrTagNotFound
+	}
+
+	tag, err := s.queries.RenameTag(ctx, db.RenameTagParams{
+		ID:     tid,
+		UserID: uid,
+		Name:   strings.TrimSpace(name),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Tag{}, ErrTagNotFound
+		}
+		if pgErrorCode(err) == pgUniqueViolation {
+			return db.Tag{}, ErrTagExists
+		}
+		return db.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+// Delete removes the tag from every product carrying it
+func (s *TagService) Delete(ctx context.Context, userID, tagID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrTagNotFound
+	}
+	tid, err := uuid.Parse(tagID)
+	if err != nil {
+		return ErrTagNotFound
+	}
+
+	deleted, err := s.queries.DeleteTag(ctx, db.DeleteTagParams{ID: tid, UserID: uid})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
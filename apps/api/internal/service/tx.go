@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/falasefemi2/goreact-boilerplate/internal/db"
+)
+
+// withTx runs fn inside a transaction, committing if it returns nil
+// and rolling back otherwise
+func withTx(ctx context.Context, database *sql.DB, fn func(q *db.Queries) error) error {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(db.New(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
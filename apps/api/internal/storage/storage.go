@@ -0,0 +1,48 @@
+// Package storage keeps uploaded files (blobs) out of the database,
+// behind an interface with a local-disk and an S3-compatible backend.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores blobs under slash-separated keys such as
+// "products/<id>/<image>.jpg"
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get returns ErrNotFound if there is no blob under key. The caller
+	// must close the returned body.
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Delete succeeds if the blob is already gone
+	Delete(ctx context.Context, key string) error
+}
+
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	// Size is -1 when the backend doesn't report it
+	Size int64
+}
+
+// validKey rejects keys that could escape the store's root or that
+// different backends would treat differently
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -2,12 +2,59 @@ package validator
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 
+	"github.com/falasefemi2/goreact-boilerplate/internal/money"
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+var validate = newValidate()
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+	// registering only fails for a malformed tag name
+	if err := v.RegisterValidation("decimal", validateDecimal); err != nil {
+		panic(err)
+	}
+	if err := v.RegisterValidation("currency", validateCurrency); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// validateDecimal backs `decimal=P S`: the string must be a plain
+// decimal that fits NUMERIC(P, S), i.e. at most S digits after the
+// point and P-S before it
+func validateDecimal(fl validator.FieldLevel) bool {
+	precision, scale, ok := decimalParam(fl.Param())
+	if !ok {
+		panic(fmt.Sprintf("validator: bad decimal param %q", fl.Param()))
+	}
+
+	d, err := money.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	minScale := d.MinScale()
+	return int(minScale) <= scale && d.Precision()-int(minScale) <= precision-scale
+}
+
+func decimalParam(param string) (precision, scale int, ok bool) {
+	p, s, found := strings.Cut(param, " ")
+	if !found {
+		return 0, 0, false
+	}
+	precision, err1 := strconv.Atoi(p)
+	scale, err2 := strconv.Atoi(s)
+	return precision, scale, err1 == nil && err2 == nil && scale <= precision
+}
+
+// validateCurrency backs `currency`: an ISO 4217 code like USD
+func validateCurrency(fl validator.FieldLevel) bool {
+	return money.ValidCurrency(fl.Field().String())
+}
 
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -37,9 +84,22 @@ func fieldMessage(e validator.FieldError) string {
 	case "email":
 		return "must be a valid email address"
 	case "min":
+		if e.Kind() != reflect.String {
+			return fmt.Sprintf("must be at least %s", e.Param())
+		}
 		return fmt.Sprintf("must be at least %s characters", e.Param())
 	case "max":
+		if e.Kind() != reflect.String {
+			return fmt.Sprintf("must be at most %s", e.Param())
+		}
 		return fmt.Sprintf("must be at most %s characters", e.Param())
+	case "numeric":
+		return "must be a number"
+	case "decimal":
+		precision, scale, _ := decimalParam(e.Param())
+		return fmt.Sprintf("must be a decimal number with at most %d digits before the point and %d after", precision-scale, scale)
+	case "currency":
+		return "must be an ISO 4217 currency code"
 	default:
 		return fmt.Sprintf("%s is invalid", strings.ToLower(e.Field()))
 	}
@@ -1,4 +1,4 @@
-import axios from "axios";
+import axios, { type AxiosRequestConfig } from "axios";
 import type { ApiError } from "../types/api";
 
 export const client = axios.create({
@@ -9,10 +9,51 @@ export const client = axios.create({
   },
 });
 
-// Response interceptor — handle errors globally
+type RetriableRequest = AxiosRequestConfig & { _retried?: boolean };
+
+// endpoints where a 401 means "bad credentials", not "access token expired"
+const NO_REFRESH_PATHS = [
+  "/api/v1/auth/login",
+  "/api/v1/auth/register",
+  "/api/v1/auth/refresh",
+  "/api/v1/auth/logout",
+];
+
+// a single in-flight refresh shared by every request that hit a 401
+let refreshing: Promise<void> | null = null;
+
+function refreshSession() {
+  refreshing ??= client
+    .post("/api/v1/auth/refresh")
+    .then(() => undefined)
+    .finally(() => {
+      refreshing = null;
+    });
+  return refreshing;
+}
+
+// Response interceptor — refresh expired access tokens, then handle errors globally
 client.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const original = error.config as RetriableRequest | undefined;
+    const skipRefresh = NO_REFRESH_PATHS.includes(original?.url ?? "");
+
+    if (
+      error.response?.status === 401 &&
+      original &&
+      !original._retried &&
+      !skipRefresh
+    ) {
+      original._retried = true;
+      try {
+        await refreshSession();
+        return client(original);
+      } catch {
+        // refresh failed — fall through and surface the original error
+      }
+    }
+
     const apiError: ApiError = error.response?.data ?? {
       error: "Something went wrong",
     };
@@ -1,65 +1,387 @@
-// mirrors Go's response wrapper
-export interface ApiResponse<T> {
-  data: T;
-}
-
-export interface ApiError {
-  error: string;
-  fields?: ValidationError[];
-}
-
-export interface ValidationError {
-  field: string;
-  message: string;
-}
-
-// mirrors Go's User struct
-export interface User {
-  id: string;
-  email: string;
-  role: string;
-  created_at: string;
-  updated_at: string;
-}
-
-interface NullableString {
-  String: string;
-  Valid: boolean;
-}
-
-// mirrors Go's Product struct
-export interface Product {
-  id: string;
-  user_id: string;
-  name: string;
-  description: NullableString;
-  price: string;
-  stock: number;
-  created_at: string;
-  updated_at: string;
-}
-
-// request types
-export interface LoginRequest {
-  email: string;
-  password: string;
-}
-
-export interface RegisterRequest {
-  email: string;
-  password: string;
-}
-
-export interface CreateProductRequest {
-  name: string;
-  description?: string;
-  price: string;
-  stock: number;
-}
-
-export interface UpdateProductRequest {
-  name?: string;
-  description?: string;
-  price?: string;
-  stock?: number;
-}
+// mirrors Go's response wrapper
+export interface ApiResponse<T> {
+  data: T;
+}
+
+export interface ApiError {
+  error: string;
+  fields?: ValidationError[];
+}
+
+export interface ValidationError {
+  field: string;
+  message: string;
+}
+
+// mirrors Go's User struct
+export interface User {
+  id: string;
+  email: string;
+  role: string;
+  created_at: string;
+  updated_at: string;
+}
+
+interface NullableString {
+  String: string;
+  Valid: boolean;
+}
+
+interface NullableTime {
+  Time: string;
+  Valid: boolean;
+}
+
+// mirrors Go's Product struct
+export interface Product {
+  id: string;
+  user_id: string;
+  name: string;
+  description: NullableString;
+  price: string;
+  stock: number;
+  created_at: string;
+  updated_at: string;
+  // bumped on every write; the ETag is derived from it
+  version: number;
+  // set while the product is in the trash
+  deleted_at: NullableTime;
+  // ISO 4217 code the price is in
+  currency: string;
+  // over approved reviews; the average is null until there is one
+  rating_count: number;
+  rating_average: string | null;
+  // shown in the public catalog
+  is_published: boolean;
+}
+
+// mirrors Go's CatalogProduct struct: what anonymous visitors see of a
+// published product
+export interface CatalogProduct {
+  id: string;
+  name: string;
+  description: NullableString;
+  price: string;
+  currency: string;
+  in_stock: boolean;
+  rating_count: number;
+  rating_average: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+interface NullableInt32 {
+  Int32: number;
+  Valid: boolean;
+}
+
+// mirrors Go's ProductVariant struct; a null price or an invalid stock
+// means the variant takes the product's
+export interface ProductVariant {
+  id: string;
+  product_id: string;
+  user_id: string;
+  sku: string;
+  options: Record<string, string>;
+  price: string | null;
+  stock: NullableInt32;
+  created_at: string;
+  updated_at: string;
+}
+
+// what GET /products?include=variants returns
+export interface ProductWithVariants extends Product {
+  variants: ProductVariant[];
+}
+
+// mirrors Go's Category struct; parent_id is null for top-level categories
+export interface Category {
+  id: string;
+  user_id: string;
+  parent_id: string | null;
+  name: string;
+  created_at: string;
+  updated_at: string;
+}
+
+// mirrors Go's Tag struct
+export interface Tag {
+  id: string;
+  user_id: string;
+  name: string;
+  created_at: string;
+}
+
+// mirrors the Go service.ProductImage; the URLs are signed and stop
+// working at url_expires_at, so fetch the list again to refresh them
+export interface ProductImage {
+  id: string;
+  product_id: string;
+  content_type: string;
+  size_bytes: number;
+  width: number;
+  height: number;
+  created_at: string;
+  url: string;
+  thumbnail_url: string;
+  url_expires_at: string;
+}
+
+// mirrors Go's StockMovement struct; delta is signed and stock_after is
+// the stock once it was applied: the variant's when variant_id is set,
+// otherwise the product's
+export interface StockMovement {
+  id: string;
+  product_id: string;
+  user_id: string | null;
+  reason: "restock" | "sale" | "adjustment" | "return";
+  delta: number;
+  stock_after: number;
+  note: NullableString;
+  created_at: string;
+  variant_id: string | null;
+}
+
+// mirrors Go's PriceHistory struct; user_id is whoever made or
+// scheduled the change
+export interface PriceHistoryEntry {
+  id: string;
+  product_id: string;
+  user_id: string | null;
+  price: string;
+  currency: string;
+  reason: "initial" | "update" | "scheduled";
+  scheduled_change_id: string | null;
+  created_at: string;
+}
+
+// mirrors Go's ScheduledPriceChange struct; skipped changes were due
+// after the product had moved to another currency
+export interface ScheduledPriceChange {
+  id: string;
+  product_id: string;
+  user_id: string | null;
+  price: string;
+  currency: string;
+  effective_at: string;
+  status: "pending" | "applied" | "skipped";
+  processed_at: NullableTime;
+  created_at: string;
+}
+
+export type OrderStatus = "pending" | "paid" | "shipped" | "delivered" | "cancelled";
+
+// mirrors Go's db.Order; the *_at stamps are set when the order reaches
+// that status
+export interface Order {
+  id: string;
+  user_id: string;
+  status: OrderStatus;
+  total: string;
+  created_at: string;
+  updated_at: string;
+  paid_at: NullableTime;
+  shipped_at: NullableTime;
+  delivered_at: NullableTime;
+  cancelled_at: NullableTime;
+  // every item on an order is in this currency
+  currency: string;
+  // total is subtotal less discount
+  subtotal: string;
+  discount: string;
+}
+
+// name and unit_price are copied at checkout; product_id is null once
+// the product has been purged
+export interface OrderItem {
+  id: string;
+  order_id: string;
+  product_id: string | null;
+  product_name: string;
+  unit_price: string;
+  quantity: number;
+}
+
+export interface OrderWithItems extends Order {
+  items: OrderItem[];
+}
+
+export type CartIssue =
+  | "unavailable"
+  | "insufficient_stock"
+  | "price_changed"
+  | "currency_mismatch";
+
+// mirrors Go's money.Money; amount is exact, never parse it as a float
+export interface Money {
+  amount: string;
+  currency: string;
+}
+
+// mirrors Go's service.CartItem: unit_price is today's price and
+// added_price the one the item was added at
+export interface CartItem {
+  product_id: string;
+  name: string;
+  quantity: number;
+  unit_price: string;
+  added_price: string;
+  line_total: string;
+  currency: string;
+  // exact stock isn't shared; insufficient_stock says when there's too little
+  in_stock: boolean;
+  issues: CartIssue[];
+}
+
+// id is the zero UUID until something has been added
+export interface Cart {
+  id: string;
+  items: CartItem[];
+  subtotal: Money;
+}
+
+// mirrors Go's service.Promotion. A promotion without a code applies
+// by itself; one without product_ids applies to the whole order.
+export interface Promotion {
+  id: string;
+  name: string;
+  code: NullableString;
+  kind: "percentage" | "fixed";
+  // a percentage, or an amount in currency
+  value: string;
+  currency: NullableString;
+  min_order: string | null;
+  max_uses: NullableInt32;
+  max_uses_per_user: NullableInt32;
+  starts_at: NullableTime;
+  ends_at: NullableTime;
+  active: boolean;
+  created_by: string | null;
+  created_at: string;
+  updated_at: string;
+  product_ids: string[];
+}
+
+export type CouponRejection =
+  | "inactive"
+  | "not_started"
+  | "expired"
+  | "currency"
+  | "min_order"
+  | "usage_limit"
+  | "user_limit"
+  | "nothing_to_discount";
+
+export interface AppliedDiscount {
+  promotion_id: string;
+  name: string;
+  code: string | null;
+  amount: Money;
+}
+
+// mirrors Go's service.Evaluation
+export interface Evaluation {
+  subtotal: Money;
+  discounts: AppliedDiscount[];
+  discount: Money;
+  total: Money;
+  coupon_rejection?: CouponRejection;
+}
+
+export type ReviewStatus = "pending" | "approved" | "rejected";
+
+// mirrors Go's db.Review; only approved reviews are shown to others
+export interface Review {
+  id: string;
+  product_id: string;
+  user_id: string;
+  // 1 to 5 stars
+  rating: number;
+  body: string;
+  status: ReviewStatus;
+  moderated_by: string | null;
+  moderated_at: NullableTime;
+  created_at: string;
+  updated_at: string;
+}
+
+// request types
+export interface LoginRequest {
+  email: string;
+  password: string;
+}
+
+export interface RegisterRequest {
+  email: string;
+  password: string;
+}
+
+export interface CreateProductRequest {
+  name: string;
+  description?: string;
+  price: string;
+  currency?: string;
+  stock: number;
+  // unpublished by default
+  is_published?: boolean;
+}
+
+// replaces every field; leave out price or stock to use the product's
+export interface VariantRequest {
+  sku: string;
+  options: Record<string, string>;
+  price?: string;
+  stock?: number;
+}
+
+export interface SchedulePriceRequest {
+  price: string;
+  // RFC 3339, in the future
+  effective_at: string;
+}
+
+export interface CreateOrderRequest {
+  items: { product_id: string; quantity: number }[];
+  coupon_code?: string;
+}
+
+export interface EvaluateRequest {
+  items: { product_id: string; quantity: number }[];
+  coupon_code?: string;
+}
+
+// replaces every field; active defaults to true
+export interface PromotionRequest {
+  name: string;
+  code?: string;
+  kind: "percentage" | "fixed";
+  value: string;
+  currency?: string;
+  min_order?: string;
+  max_uses?: number;
+  max_uses_per_user?: number;
+  starts_at?: string;
+  ends_at?: string;
+  active?: boolean;
+  product_ids?: string[];
+}
+
+// editing a review sends it back to moderation
+export interface ReviewRequest {
+  rating: number;
+  body?: string;
+}
+
+export interface AddCartItemRequest {
+  product_id: string;
+  quantity: number;
+}
+
+export interface UpdateProductRequest {
+  name?: string;
+  description?: string;
+  price?: string;
+  currency?: string;
+  stock?: number;
+  is_published?: boolean;
+}